@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"app/lib"
 
@@ -20,26 +24,71 @@ type App struct {
 	windowStates *lib.WindowStateStore
 	configStore  *lib.ConfigStore
 	config       *lib.AppConfig
+	profiles     *lib.ProfileStore
+	history      *lib.HistoryStore
+	// Guards config & configStore, which are also accessed by the config file watcher.
+	configMu sync.Mutex
+	// Reports messages produced outside of runs.
+	reporter lib.Reporter
+	messages *lib.MessageStore
+	// Whether a `messages` event is already scheduled.
+	notifyMu      sync.Mutex
+	notifyPending bool
+	// Messages produced before the frontend was ready to receive them.
+	startupMessages []*lib.Message
+	// Arguments the app was launched with, processed once the frontend is ready.
+	launchArgs []string
+
+	operations operationGroup
+
+	// Messages of the last finished run, for reports, and what it ran on, to repeat it.
+	lastRunMu      sync.Mutex
+	lastRun        []*lib.Message
+	lastRunRequest *runRequest
+
+	// Stops of watched package directories, by path.
+	watchesMu sync.Mutex
+	watches   map[string]context.CancelFunc
 }
 
 // NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+func NewApp(launchArgs []string) *App {
+	return &App{launchArgs: launchArgs, watches: map[string]context.CancelFunc{}}
 }
 
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.messages = lib.NewMessageStore()
+	a.reporter = a.runReporter(0)
 
 	// Create & load config files
-	a.config = &lib.AppConfig{}
-	a.configStore = lib.NewConfigStore(a.getConfigPath("config"))
-	a.windowStates = lib.NewWindowStateStore(a.getConfigPath("windows"))
-	lib.Check(a.configStore.Load(a.config))
-	lib.Check(a.windowStates.Load())
+	a.config = lib.NewAppConfig()
+	a.configStore = lib.NewAppConfigStore(getConfigPath("config"))
+	a.history = lib.NewHistoryStore(getDataDir("history"))
+	a.windowStates = lib.NewWindowStateStore(getConfigPath("windows"))
+	a.profiles = lib.NewProfileStore(getConfigDir("profiles"))
+	if badPath, err := a.configStore.LoadOrRecover(a.config); err != nil {
+		a.config = lib.NewAppConfig()
+		a.startupMessages = append(a.startupMessages, configErrorMessage(a.configStore.Path, badPath, err))
+	}
+	if badPath, err := a.windowStates.LoadOrRecover(); err != nil {
+		a.startupMessages = append(a.startupMessages, configErrorMessage(getConfigPath("windows"), badPath, err))
+	}
 	a.applyConfig()
 
+	// Pick up config changes made outside of the app
+	if err := lib.WatchFile(ctx, a.configStore.Path, a.reloadConfig); err != nil {
+		a.startupMessages = append(a.startupMessages, &lib.Message{Icon: lib.Ptr("file"), Title: a.configStore.Path, Notes: []lib.Note{{
+			Variant: lib.Warning,
+			Rule:    "config-watch-failed",
+			Code:    "CPU009",
+			Text:    "Couldn't watch config file for changes. External edits will be picked up after restart.",
+			Details: lib.Ptr(err.Error()),
+		}}})
+	}
+
 	// Restore main window position/size
 	mainWindow, ok := a.windowStates.Get("main")
 	if !ok {
@@ -49,9 +98,44 @@ func (a *App) startup(ctx context.Context) {
 	runtime.WindowSetSize(a.ctx, mainWindow.Width, mainWindow.Height)
 }
 
-// Greet returns a greeting for the given name
+// domReady is called when the frontend is loaded and listening to events
+func (a *App) domReady(ctx context.Context) {
+	for _, message := range a.startupMessages {
+		a.reporter.Message(message)
+	}
+	a.startupMessages = nil
+
+	if len(a.launchArgs) > 0 {
+		workingDir, _ := os.Getwd()
+		go a.openArgs(a.launchArgs, workingDir)
+		a.launchArgs = nil
+	}
+}
+
+// Brings the window up and processes paths the second instance was launched with.
 func (a *App) onSecondInstanceLaunch(secondInstanceData options.SecondInstanceData) {
+	runtime.WindowUnminimise(a.ctx)
 	runtime.WindowShow(a.ctx)
+	go a.openArgs(secondInstanceData.Args, secondInstanceData.WorkingDirectory)
+}
+
+// Runs operations requested by launch arguments, see `parseLaunchArgs`.
+func (a *App) openArgs(args []string, workingDir string) {
+	requests, unknown := parseLaunchArgs(args, workingDir)
+	if len(unknown) > 0 {
+		a.reporter.Message(&lib.Message{Title: "Launch arguments", Notes: []lib.Note{lib.Note{
+			Variant: lib.Warning,
+			Rule:    "unknown-argument",
+			Code:    "CPU011",
+			Details: lib.Ptr(strings.Join(unknown, " ")),
+		}.WithSegments(
+			lib.Plain("Ignored unknown arguments. Supported are "), lib.Code("--init"), lib.Plain(", "), lib.Code("--fix"),
+			lib.Plain(", and "), lib.Code("--gender"), lib.Plain(" followed by paths."),
+		)}})
+	}
+	for _, request := range requests {
+		a.run(request.operation, request.paths, lib.RunOptions{})
+	}
 }
 
 func (a *App) getWindowState() *lib.WindowState {
@@ -61,7 +145,12 @@ func (a *App) getWindowState() *lib.WindowState {
 }
 
 func (a *App) beforeClose(ctx context.Context) (prevent bool) {
-	a.configStore.Save(a.config)
+	// Don't clobber changes made to the file since we last loaded or saved it
+	a.configMu.Lock()
+	if !a.configStore.ChangedOnDisk() {
+		a.configStore.Save(a.config)
+	}
+	a.configMu.Unlock()
 	// Save window state when window is not fullscreen/minimized/maximized.
 	// Ideally this should happen after window move/resize events, but there
 	// is no way to tap into position in wails atm :(
@@ -72,19 +161,119 @@ func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 	return false
 }
 
-func (a *App) getConfigPath(name string) string {
+// Message telling the user a config file couldn't be loaded and defaults are used instead.
+func configErrorMessage(path string, badPath string, err error) *lib.Message {
+	if errors.Is(err, lib.ErrNewerConfig) {
+		return &lib.Message{Icon: lib.Ptr("file"), Title: path, Notes: []lib.Note{{
+			Variant: lib.Warning,
+			Rule:    "config-newer",
+			Code:    "CPU017",
+			Text:    "Config file is from a newer version of the app. Using defaults, changes won't be saved over it.",
+			Details: lib.Ptr(err.Error()),
+		}}}
+	}
+	if badPath != "" {
+		return &lib.Message{Icon: lib.Ptr("file"), Title: path, Notes: []lib.Note{lib.Note{
+			Variant: lib.Warning,
+			Rule:    "config-corrupt",
+			Code:    "CPU007",
+			Details: lib.Ptr(err.Error()),
+		}.WithSegments(lib.Plain("Config file was corrupt and has been moved to "), lib.PathSegment(badPath), lib.Plain(". Using defaults."))}}
+	}
+	return &lib.Message{Icon: lib.Ptr("file"), Title: path, Notes: []lib.Note{{
+		Variant: lib.Error,
+		Rule:    "config-load-failed",
+		Code:    "CPU008",
+		Text:    "Couldn't load config file. Using defaults.",
+		Details: lib.Ptr(err.Error()),
+	}}}
+}
+
+func getConfigPath(name string) string {
 	return lib.Must(xdg.ConfigFile(filepath.Join("Clothing Plugins Util", name+".json")))
 }
 
-func (a *App) message(message *lib.Message) {
-	runtime.EventsEmit(a.ctx, "message", message)
+func getConfigDir(name string) string {
+	return filepath.Join(xdg.ConfigHome, "Clothing Plugins Util", name)
+}
+
+func getDataDir(name string) string {
+	return filepath.Join(xdg.DataHome, "Clothing Plugins Util", name)
+}
+
+func getCacheDir(name string) string {
+	return filepath.Join(xdg.CacheHome, "Clothing Plugins Util", name)
+}
+
+// How often at most the frontend is told to query messages again.
+const messagesNotifyInterval = 100 * time.Millisecond
+
+// Reports operation output to the frontend. Messages are kept in the message store and announced by
+// throttled `messages` events carrying what changed since the last one, progress updates are sent as `progress` events.
+type wailsReporter struct {
+	app   *App
+	store lib.Reporter
+}
+
+func (a *App) runReporter(run int) *wailsReporter {
+	return &wailsReporter{app: a, store: a.messages.RunReporter(run)}
+}
+
+func (r *wailsReporter) Message(message *lib.Message) {
+	r.store.Message(message)
+	r.app.notifyMessages()
+}
+
+func (r *wailsReporter) Progress(progress *lib.Progress) {
+	runtime.EventsEmit(r.app.ctx, "progress", progress)
+}
+
+func (a *App) notifyMessages() {
+	a.notifyMu.Lock()
+	defer a.notifyMu.Unlock()
+	if a.notifyPending {
+		return
+	}
+	a.notifyPending = true
+	time.AfterFunc(messagesNotifyInterval, func() {
+		a.notifyMu.Lock()
+		a.notifyPending = false
+		a.notifyMu.Unlock()
+		runtime.EventsEmit(a.ctx, "messages", a.messages.TakeChanges())
+	})
+}
+
+// Returns a page of stored messages matching the query, newest first.
+func (a *App) QueryMessages(query lib.MessageQuery) *lib.MessagePage {
+	return a.messages.Query(query)
+}
+
+// Lists runs whose messages are stored, oldest first.
+func (a *App) ListRuns() []*lib.StoredRun {
+	return a.messages.Runs()
+}
+
+// Drops stored messages of all finished runs.
+func (a *App) ClearMessages() {
+	a.messages.Clear()
+	a.notifyMessages()
 }
 
 func (a *App) GetConfig() *lib.AppConfig {
+	a.configMu.Lock()
+	defer a.configMu.Unlock()
 	return a.config
 }
 
 func (a *App) SetConfig(config *lib.AppConfig) error {
+	config.Version = lib.AppConfigVersion
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.configMu.Lock()
+	defer a.configMu.Unlock()
+
 	oldConfig := a.config
 	a.config = config
 
@@ -103,112 +292,422 @@ func (a *App) applyConfig() {
 	runtime.WindowSetAlwaysOnTop(a.ctx, a.config.OnTop)
 }
 
-var clothingVajExp = regexp.MustCompile(`(?i).*/custom/clothing/(?:female|male)/[^/]+/[^/]+/.*\.vaj$`)
-var clothingVapExps = []*regexp.Regexp{
-	regexp.MustCompile(`(?i).*/custom/clothing/(?:female|male)/[^/]+/[^/]+/.*\.vap$`),
-	regexp.MustCompile(`(?i).*/custom/atom/person/appearance/.*\.vap$`),
-	regexp.MustCompile(`(?i).*/custom/atom/person/clothing/.*\.vap$`),
+// Reloads config after the file was changed on disk. Invalid files are reported and current config is kept.
+func (a *App) reloadConfig() {
+	a.configMu.Lock()
+	defer a.configMu.Unlock()
+
+	// Ignore events caused by our own saves
+	if !a.configStore.ChangedOnDisk() {
+		return
+	}
+
+	config := lib.NewAppConfig()
+	if err := a.configStore.Load(config); err != nil {
+		a.reporter.Message(&lib.Message{Icon: lib.Ptr("file"), Title: a.configStore.Path, Notes: []lib.Note{{
+			Variant: lib.Error,
+			Rule:    "config-reload-failed",
+			Code:    "CPU010",
+			Text:    "Config file changed, but couldn't be loaded. Keeping current settings.",
+			Details: lib.Ptr(err.Error()),
+		}}})
+		return
+	}
+
+	a.config = config
+	a.applyConfig()
+	runtime.EventsEmit(a.ctx, "config", a.config)
 }
-var clothingCplExp = regexp.MustCompile(`(?i).*/custom/clothing/(?:female|male)/[^/]+/[^/]+/.*\.clothingplugins$`)
 
-// Initializes Clothing Plugin Manager in .vaj files
-func (a *App) InitPaths(paths []string) {
-	for _, path := range paths {
-		err := filepath.Walk(path, func(walkedPath string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
+// Lists names of all profiles, including the active one.
+func (a *App) ListProfiles() ([]string, error) {
+	names, err := a.profiles.List()
+	if err != nil {
+		return nil, err
+	}
+	if active := a.GetConfig().ActiveProfile(); !slices.Contains(names, active) {
+		names = append(names, active)
+		slices.Sort(names)
+	}
+	return names, nil
+}
+
+// Stores current config in its profile and makes the named profile the active config.
+func (a *App) SwitchProfile(name string) error {
+	a.configMu.Lock()
+	defer a.configMu.Unlock()
+
+	if name == a.config.ActiveProfile() {
+		return nil
+	}
+	config, err := a.profiles.Load(name)
+	if err != nil {
+		return err
+	}
+	if err := a.profiles.Save(a.config.ActiveProfile(), a.config); err != nil {
+		return err
+	}
+	if name == lib.DefaultProfile {
+		config.Profile = ""
+	}
+	if err := a.configStore.Save(config); err != nil {
+		return err
+	}
+
+	a.config = config
+	a.applyConfig()
+	runtime.EventsEmit(a.ctx, "config", a.config)
+	return nil
+}
+
+// Creates a new profile with settings of an existing one.
+func (a *App) DuplicateProfile(name string, newName string) error {
+	config, err := a.profileConfig(name)
+	if err != nil {
+		return err
+	}
+	if newName == a.GetConfig().ActiveProfile() || a.profiles.Exists(newName) {
+		return fmt.Errorf("%w: \"%s\"", lib.ErrProfileExists, newName)
+	}
+	return a.profiles.Save(newName, config)
+}
 
-			normalizedPath := filepath.ToSlash(walkedPath)
+// Deletes a profile. Active profile can't be deleted, switch to a different one first.
+func (a *App) DeleteProfile(name string) error {
+	if name == a.GetConfig().ActiveProfile() {
+		return fmt.Errorf("can't delete active profile \"%s\"", name)
+	}
+	return a.profiles.Delete(name)
+}
 
-			if clothingVajExp.MatchString(normalizedPath) {
-				a.message(lib.FixVaj(normalizedPath, false))
-			}
+// Asks for a file and exports the profile into it. Returns the file path, or empty string when cancelled.
+func (a *App) ExportProfile(name string) (string, error) {
+	config, err := a.profileConfig(name)
+	if err != nil {
+		return "", err
+	}
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export profile",
+		DefaultFilename: name + ".json",
+		Filters:         []runtime.FileFilter{{DisplayName: "Profile (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+	return filePath, lib.ExportProfile(config, filePath)
+}
 
-			return nil
-		})
-		if err != nil {
-			a.message(&lib.Message{Icon: lib.Ptr("file"), Title: path, Notes: []lib.Note{{
-				Variant: "danger",
-				Text:    fmt.Sprintf("Failed to walk path \"%s\".", path),
-				Details: lib.Ptr(err.Error()),
-			}}})
-		}
+// Asks for an exported profile file and adds it to profiles without switching to it.
+// Returns name of the imported profile, or empty string when cancelled.
+func (a *App) ImportProfile() (string, error) {
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import profile",
+		Filters: []runtime.FileFilter{{DisplayName: "Profile (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+	config, err := lib.ImportProfile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if config.Profile == a.GetConfig().ActiveProfile() || a.profiles.Exists(config.Profile) {
+		return "", fmt.Errorf("%w: \"%s\"", lib.ErrProfileExists, config.Profile)
 	}
+	return config.Profile, a.profiles.Save(config.Profile, config)
+}
+
+// Active profile's config is always the current one, stored profile file might be outdated.
+func (a *App) profileConfig(name string) (*lib.AppConfig, error) {
+	if config := a.GetConfig(); name == config.ActiveProfile() {
+		return config.Clone(), nil
+	}
+	return a.profiles.Load(name)
+}
+
+// Initializes Clothing Plugin Manager in .vaj files
+func (a *App) InitPaths(paths []string) {
+	a.run(lib.OpInit, paths, lib.RunOptions{})
 }
 
 // Fixes .vaj, .clothingplugins, and .vap files for release
 func (a *App) FixPaths(paths []string) {
-	for _, path := range paths {
-		err := filepath.Walk(path, func(walkedPath string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
-
-			normalizedPath := filepath.ToSlash(walkedPath)
-
-			if lib.ItemGenderExp.MatchString(normalizedPath) {
-				a.message(lib.FixItemGender(normalizedPath))
-			}
-
-			if clothingVajExp.MatchString(normalizedPath) {
-				a.message(lib.FixVaj(normalizedPath, true))
-			} else if clothingCplExp.MatchString(normalizedPath) {
-				a.message(lib.FixCpl(normalizedPath))
-			} else {
-				for _, exp := range clothingVapExps {
-					if exp.MatchString(normalizedPath) {
-						a.message(lib.FixVap(normalizedPath))
-						break
-					}
-				}
-			}
-
-			return nil
-		})
-		if err != nil {
-			a.message(&lib.Message{Icon: lib.Ptr("file"), Title: path, Notes: []lib.Note{{
-				Variant: "danger",
-				Text:    fmt.Sprintf("Failed to walk path \"%s\".", path),
-				Details: lib.Ptr(err.Error()),
-			}}})
-		}
-	}
+	a.run(lib.OpFix, paths, lib.RunOptions{})
 }
 
 // Fixes gender in hair & clothing .vam files to match the directory they are in
 func (a *App) FixItemsGender(paths []string) {
-	for _, path := range paths {
-		err := filepath.Walk(path, func(walkedPath string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
-
-			normalizedPath := filepath.ToSlash(walkedPath)
-
-			if lib.ItemGenderExp.MatchString(normalizedPath) {
-				a.message(lib.FixItemGender(normalizedPath))
-			}
-
-			return nil
-		})
-		if err != nil {
-			a.message(&lib.Message{Icon: lib.Ptr("file"), Title: path, Notes: []lib.Note{{
-				Variant: "danger",
-				Text:    fmt.Sprintf("Failed to walk path \"%s\".", path),
-				Details: lib.Ptr(err.Error()),
-			}}})
-		}
+	a.run(lib.OpGender, paths, lib.RunOptions{})
+}
+
+// Runs an operation with options, e.g. only some fixers enabled, or forced to process unchanged files.
+func (a *App) RunPaths(operation string, paths []string, options lib.RunOptions) error {
+	op, err := lib.ParseOperation(operation)
+	if err != nil {
+		return err
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	a.run(op, paths, options)
+	return nil
+}
+
+// Lists all available fixers.
+func (a *App) ListFixers() []lib.FixerInfo {
+	return lib.ListFixers()
+}
+
+// Operation to run on paths, see `App.run`, or a job file, see `App.runJob`.
+type runRequest struct {
+	operation lib.Operation
+	paths     []string
+	options   lib.RunOptions
+	job       string
+}
+
+func (a *App) run(operation lib.Operation, paths []string, options lib.RunOptions) *lib.Summary {
+	ctx, cancel := a.operations.start(a.ctx)
+	defer cancel()
+	// Repeated runs pick up current config
+	request := &runRequest{operation: operation, paths: paths, options: options}
+	options.Config = a.GetConfig()
+	options.StateDir = getCacheDir("state")
+
+	stored := a.messages.BeginRun(operation, paths)
+	summary := lib.Run(ctx, a.runReporter(stored.ID), operation, paths, options)
+	a.messages.FinishRun(stored.ID)
+	a.notifyMessages()
+
+	a.finishRun(request, stored, summary)
+	return summary
+}
+
+// Runs steps of a job file.
+func (a *App) runJob(filePath string) error {
+	job, err := lib.LoadJob(filePath)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := a.operations.start(a.ctx)
+	defer cancel()
+
+	stored := a.messages.BeginRun("job", []string{filePath})
+	options := lib.RunOptions{Config: a.GetConfig(), StateDir: getCacheDir("state")}
+	summary := lib.RunJob(ctx, a.runReporter(stored.ID), job, options)
+	a.messages.FinishRun(stored.ID)
+	a.notifyMessages()
+
+	a.finishRun(&runRequest{job: filePath}, stored, summary)
+	return nil
+}
+
+// Remembers the finished run to repeat and export it, and stores it in history. Returns its messages.
+func (a *App) finishRun(request *runRequest, stored *lib.StoredRun, summary *lib.Summary) []*lib.Message {
+	messages := a.messages.Messages(stored.ID)
+	a.lastRunMu.Lock()
+	a.lastRun = messages
+	a.lastRunRequest = request
+	a.lastRunMu.Unlock()
+
+	if _, err := a.history.Add(summary, messages); err != nil {
+		a.reporter.Message(&lib.Message{Icon: lib.Ptr("file"), Title: a.history.Dir, Notes: []lib.Note{{
+			Variant: lib.Warning,
+			Rule:    "history-save-failed",
+			Code:    "CPU012",
+			Text:    "Couldn't save the run to history.",
+			Details: lib.Ptr(err.Error()),
+		}}})
+	} else {
+		a.emitHistory()
+	}
+	return messages
+}
+
+// Runs steps of a job file, see README for its format.
+func (a *App) RunJobFile(filePath string) error {
+	return a.runJob(filePath)
+}
+
+// Asks for a job file and runs its steps. Returns the file path, or empty string when cancelled.
+func (a *App) RunJob() (string, error) {
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Run job",
+		Filters: []runtime.FileFilter{{DisplayName: "Job (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+	return filePath, a.runJob(filePath)
+}
+
+// Repeats the last run on the same paths with the same options.
+func (a *App) RerunLast() error {
+	a.lastRunMu.Lock()
+	request := a.lastRunRequest
+	a.lastRunMu.Unlock()
+	if request == nil {
+		return fmt.Errorf("there is no finished run to repeat")
+	}
+	if request.job != "" {
+		return a.runJob(request.job)
+	}
+	a.run(request.operation, request.paths, request.options)
+	return nil
+}
+
+// Repeats the last run only on files that got errors or warnings in it.
+func (a *App) RerunFailed() error {
+	a.lastRunMu.Lock()
+	request, messages := a.lastRunRequest, a.lastRun
+	a.lastRunMu.Unlock()
+	if request == nil {
+		return fmt.Errorf("there is no finished run to repeat")
+	}
+	if request.job != "" {
+		return fmt.Errorf("failed files of a job can't be re-run on their own, re-run the whole job")
+	}
+	files := lib.FailedFiles(messages)
+	if len(files) == 0 {
+		return fmt.Errorf("last run has no files with errors or warnings")
 	}
+	// Walked from the original paths, so include and ignore patterns resolve the same way
+	options := request.options
+	options.Files = files
+	a.run(request.operation, request.paths, options)
+	return nil
+}
+
+// Lists runs stored in history, newest first.
+func (a *App) ListHistory() ([]*lib.HistoryEntry, error) {
+	return a.history.List()
+}
+
+// Loads messages of a run stored in history.
+func (a *App) LoadHistoryRun(id string) (*lib.HistoryRun, error) {
+	return a.history.Load(id)
+}
+
+// Adds messages of a run stored in history to the message store, so they're shown again.
+func (a *App) OpenHistoryRun(id string) (*lib.StoredRun, error) {
+	run, err := a.history.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Summary == nil {
+		return nil, fmt.Errorf("run \"%s\" has no summary", id)
+	}
+	stored := a.messages.AddRun(run.Summary, run.Messages)
+	a.notifyMessages()
+	return stored, nil
+}
+
+// Finds messages about files whose path contains the query in all runs stored in history.
+func (a *App) SearchHistory(query string) ([]*lib.HistoryMatch, error) {
+	return a.history.Search(query)
+}
+
+// Deletes runs older than maxAgeDays, then the oldest runs until history fits into maxSizeMB.
+// Zero disables the respective limit. Returns the number of deleted runs.
+func (a *App) PruneHistory(maxAgeDays int, maxSizeMB int) (int, error) {
+	deleted, err := a.history.Prune(time.Duration(maxAgeDays)*24*time.Hour, int64(maxSizeMB)<<20)
+	if deleted > 0 {
+		a.emitHistory()
+	}
+	return deleted, err
+}
+
+func (a *App) emitHistory() {
+	if entries, err := a.history.List(); err == nil {
+		runtime.EventsEmit(a.ctx, "history", entries)
+	}
+}
+
+// Asks for a file and writes messages of the last run into it, format is picked by extension.
+// Returns the file path, or empty string when cancelled.
+func (a *App) ExportReport() (string, error) {
+	a.lastRunMu.Lock()
+	messages := a.lastRun
+	a.lastRunMu.Unlock()
+	if len(messages) == 0 {
+		return "", fmt.Errorf("there is no finished run to export")
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export report",
+		DefaultFilename: "report.html",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HTML (*.html)", Pattern: "*.html"},
+			{DisplayName: "Markdown (*.md)", Pattern: "*.md"},
+			{DisplayName: "JSON (*.json)", Pattern: "*.json"},
+			{DisplayName: "SARIF (*.sarif)", Pattern: "*.sarif"},
+			{DisplayName: "JUnit XML (*.xml)", Pattern: "*.xml"},
+		},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+	return filePath, lib.NewReport(messages).WriteFile(filePath)
+}
+
+// Starts fixing files in a package directory in AddonPackagesBuilder every time they change.
+func (a *App) StartWatch(path string) error {
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	a.watchesMu.Lock()
+	defer a.watchesMu.Unlock()
+
+	if _, ok := a.watches[path]; ok {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	// Hot reloads and profile switches apply to the next batch of changes
+	err := lib.Watch(ctx, a.reporter, path, lib.OpFix, a.GetConfig, lib.RunOptions{StateDir: getCacheDir("state")})
+	if err != nil {
+		cancel()
+		return err
+	}
+	a.watches[path] = cancel
+	a.emitWatches()
+	return nil
+}
+
+func (a *App) StopWatch(path string) {
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	a.watchesMu.Lock()
+	defer a.watchesMu.Unlock()
+
+	if cancel, ok := a.watches[path]; ok {
+		cancel()
+		delete(a.watches, path)
+		a.emitWatches()
+	}
+}
+
+// Lists watched package directories.
+func (a *App) ListWatches() []string {
+	a.watchesMu.Lock()
+	defer a.watchesMu.Unlock()
+	return a.watchedPaths()
+}
+
+func (a *App) watchedPaths() []string {
+	paths := make([]string, 0, len(a.watches))
+	for path := range a.watches {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func (a *App) emitWatches() {
+	runtime.EventsEmit(a.ctx, "watches", a.watchedPaths())
+}
+
+// Cancels all currently running operations.
+func (a *App) Cancel() {
+	a.operations.cancelAll()
 }
 
 // Dummy method to force wails to generate bindings for message types.
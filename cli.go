@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"app/lib"
+)
+
+const cliUsage = `Usage: %s cli [flags] <init|fix|gender|validate> <paths...>
+       %s cli [flags] job <job.json>
+
+Runs an operation, or steps of a job file, without the GUI and writes its
+messages and progress updates to stdout as JSON lines, or its messages as text.
+
+Flags:
+`
+
+// Runs an operation from the command line. Returns process exit code.
+func runCLI(args []string) int {
+	flags := flag.NewFlagSet("cli", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), cliUsage, os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+	}
+	workers := flags.Int("workers", 0, "number of files processed in parallel (0 = config value or number of CPUs)")
+	fixers := flags.String("fixers", "", "comma separated list of fixers to run (default all): "+fixerNames())
+	force := flags.Bool("force", false, "process files even when they didn't change since they were last validated")
+	format := flags.String("format", "jsonl", "stdout `format`: jsonl, or text (colored when stdout is a terminal and NO_COLOR isn't set)")
+	reportPath := flags.String("report", "", "also write messages and summary to a `file`, format by extension: .html, .md, .json, .sarif, or .xml (overrides job's report)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return 2
+	}
+
+	var job *lib.Job
+	var operation lib.Operation
+	if flags.Arg(0) == "job" {
+		if flags.NArg() != 2 {
+			flags.Usage()
+			return 2
+		}
+		var err error
+		if job, err = lib.LoadJob(flags.Arg(1)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if *reportPath != "" {
+			job.Report = *reportPath
+		}
+	} else {
+		var err error
+		if operation, err = lib.ParseOperation(flags.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	if *format != "jsonl" && *format != "text" {
+		fmt.Fprintf(os.Stderr, "unknown output format \"%s\", use jsonl or text\n", *format)
+		return 2
+	}
+	if *reportPath != "" {
+		if _, err := lib.ReportFormatFromPath(*reportPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	options := lib.RunOptions{Force: *force, StateDir: getCacheDir("state")}
+	if *fixers != "" {
+		options.Fixers = strings.Split(*fixers, ",")
+		if err := options.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	config := loadConfig(*workers)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	memory := lib.NewMemoryReporter()
+	var output lib.Reporter = lib.NewJSONLinesReporter(os.Stdout)
+	if *format == "text" {
+		output = lib.NewTextReporter(os.Stdout, colorEnabled(os.Stdout))
+	}
+	reporter := lib.MultiReporter{output, memory}
+	options.Config = config
+	var summary *lib.Summary
+	if job != nil {
+		summary = lib.RunJob(ctx, reporter, job, options)
+	} else {
+		summary = lib.Run(ctx, reporter, operation, flags.Args()[1:], options)
+	}
+
+	// Jobs write their report themselves
+	if *reportPath != "" && job == nil {
+		if err := lib.NewReport(memory.Messages()).WriteFile(*reportPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't write report: %v\n", err)
+			return 1
+		}
+	}
+
+	if summary.Variants[lib.Error] > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Loads app config, falling back to defaults when it can't be loaded. Workers override config's when set.
+func loadConfig(workers int) *lib.AppConfig {
+	config := lib.NewAppConfig()
+	if err := lib.NewAppConfigStore(getConfigPath("config")).Load(config); err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't load config, using defaults: %v\n", err)
+		config = lib.NewAppConfig()
+	}
+	if workers > 0 {
+		config.Workers = workers
+	}
+	return config
+}
+
+// Whether the file is a terminal and the user didn't opt out of colors, see https://no-color.org.
+func colorEnabled(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func fixerNames() string {
+	var names []string
+	for _, fixer := range lib.Fixers() {
+		names = append(names, fixer.Name())
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,68 +1,145 @@
-import {useState, useEffect, useRef, useMemo} from 'react';
+import {useState, useEffect, useRef, useMemo, Fragment} from 'react';
 import './App.css';
-import {GetConfig, SetConfig, InitPaths, FixPaths, FixItemsGender} from '../wailsjs/go/main/App';
+import {
+	GetConfig,
+	SetConfig,
+	RunPaths,
+	ListFixers,
+	Cancel,
+	ListProfiles,
+	SwitchProfile,
+	StartWatch,
+	StopWatch,
+	ListWatches,
+	ExportReport,
+	ListHistory,
+	OpenHistoryRun,
+	QueryMessages,
+	ClearMessages,
+	RerunLast,
+	RerunFailed,
+	RunJob,
+} from '../wailsjs/go/main/App';
 import * as runtime from '../wailsjs/runtime';
 import {lib} from '../wailsjs/go/models';
 import {useWailsFileDrop} from './lib/wails-drop-interface';
 
+// Number of messages rendered at once, more are loaded on demand.
+const pageSize = 100;
+
 function App() {
-	const [messages, setMessages] = useState<(lib.Message | 'divider')[]>([]);
+	const [page, setPage] = useState<lib.MessagePage | null>(null);
+	const [filter, setFilter] = useState<lib.MessageFilter>({});
+	const [limit, setLimit] = useState(pageSize);
+	// Bumped when stored messages change, to query them again.
+	const [messagesVersion, setMessagesVersion] = useState(0);
 	const [config, setConfig] = useState<lib.AppConfig>({
+		version: 1,
 		onTop: false,
+		workers: 0,
+		followSymlinks: false,
 	});
-	const receivedCount = useRef(0);
-	const hasMessages = messages.length > 0;
+	const isFiltered = !!(filter.variants?.length || filter.path || filter.modified);
+	const hasMessages = (page?.total ?? 0) > 0 || isFiltered;
 	const initDropzoneRef = useRef<HTMLDivElement>(null);
 	const fixDropzoneRef = useRef<HTMLDivElement>(null);
 	const fixItemsGenderDropzoneRef = useRef<HTMLDivElement>(null);
+	const watchDropzoneRef = useRef<HTMLDivElement>(null);
 	const [isDraggedOver, setIsDraggedOver] = useState(false);
 	const hideDropzonesTimeout = useRef(0);
+	const [progress, setProgress] = useState<Progress | null>(null);
+	const [fixers, setFixers] = useState<lib.FixerInfo[]>([]);
+	const [disabledFixers, setDisabledFixers] = useState<string[]>([]);
+	const [profiles, setProfiles] = useState<string[]>([]);
+	const [watches, setWatches] = useState<string[]>([]);
+	const [force, setForce] = useState(false);
+	const [history, setHistory] = useState<lib.HistoryEntry[]>([]);
 
 	useEffect(() => {
 		const disposers: (() => void)[] = [];
+		disposers.push(runtime.EventsOn('messages', () => setMessagesVersion((version) => version + 1)));
 		disposers.push(
-			runtime.EventsOn('message', (data: any) =>
-				setMessages((messages) => {
-					receivedCount.current++;
-					return [lib.Message.createFrom(data), ...messages];
-				})
-			)
+			runtime.EventsOn('progress', (data: Progress) => setProgress(data.done ? null : data))
 		);
 		GetConfig().then((config) => setConfig(config));
-		disposers.push(runtime.EventsOn('config', (data: any) => setConfig(lib.AppConfig.createFrom(data))));
+		ListFixers().then((fixers) => setFixers(fixers));
+		ListProfiles().then((profiles) => setProfiles(profiles));
+		ListWatches().then((watches) => setWatches(watches));
+		disposers.push(runtime.EventsOn('watches', (data: string[]) => setWatches(data)));
+		ListHistory().then((history) => setHistory(history), console.error);
+		disposers.push(
+			runtime.EventsOn('history', (data: any[]) =>
+				setHistory(data.map((entry) => lib.HistoryEntry.createFrom(entry)))
+			)
+		);
+		disposers.push(
+			runtime.EventsOn('config', (data: any) => {
+				setConfig(lib.AppConfig.createFrom(data));
+				ListProfiles().then((profiles) => setProfiles(profiles));
+			})
+		);
 
 		return () => {
 			disposers.forEach((d) => d());
 		};
 	}, []);
 
-	function addDivider() {
-		setMessages((messages) =>
-			messages.length > 0 && messages[0] !== 'divider' ? ['divider', ...messages] : messages
-		);
+	useEffect(() => {
+		QueryMessages(lib.MessageQuery.createFrom({run: 0, filter, offset: 0, limit})).then((page) => setPage(page), console.error);
+	}, [filter, limit, messagesVersion]);
+
+	function toggleVariantFilter(variant: string) {
+		const variants = filter.variants || [];
+		setFilter({
+			...filter,
+			variants: variants.includes(variant) ? variants.filter((v) => v !== variant) : [...variants, variant],
+		});
 	}
 
 	useWailsFileDrop(initDropzoneRef, (paths) => {
 		console.log('init', paths);
-		addDivider();
 		setIsDraggedOver(false);
-		InitPaths(paths).then(console.log, console.error);
+		runPaths('init', paths);
 	});
 
 	useWailsFileDrop(fixDropzoneRef, (paths) => {
 		console.log('fix', paths);
-		addDivider();
 		setIsDraggedOver(false);
-		FixPaths(paths).then(console.log, console.error);
+		runPaths('fix', paths);
 	});
 
 	useWailsFileDrop(fixItemsGenderDropzoneRef, (paths) => {
 		console.log('fixItemsGender', paths);
-		addDivider();
 		setIsDraggedOver(false);
-		FixItemsGender(paths).then(console.log, console.error);
+		runPaths('gender', paths);
 	});
 
+	useWailsFileDrop(watchDropzoneRef, (paths) => {
+		console.log('watch', paths);
+		setIsDraggedOver(false);
+		for (const path of paths) StartWatch(path).catch(console.error);
+	});
+
+	function runPaths(operation: string, paths: string[]) {
+		// No fixers means all of them, which is what runs before they're listed
+		const enabled = fixers.map((fixer) => fixer.name).filter((name) => !disabledFixers.includes(name));
+		if (fixers.length > 0 && enabled.length === 0) {
+			console.warn('all fixers are disabled, nothing to run');
+			return;
+		}
+		RunPaths(operation, paths, {fixers: enabled, force}).then(console.log, console.error);
+	}
+
+	function openHistoryRun(id: string) {
+		OpenHistoryRun(id).catch(console.error);
+	}
+
+	function toggleFixer(name: string) {
+		setDisabledFixers((disabled) =>
+			disabled.includes(name) ? disabled.filter((n) => n !== name) : [...disabled, name]
+		);
+	}
+
 	function handleDragOver() {
 		clearTimeout(hideDropzonesTimeout.current);
 		setIsDraggedOver(true);
@@ -76,12 +153,43 @@ function App() {
 	return (
 		<main className="App" onDragOver={handleDragOver}>
 			<section className="messages">
-				{messages.map((message, i) =>
-					message == 'divider' ? (
-						<hr key={receivedCount.current + i} />
-					) : (
-						<Message key={receivedCount.current + i} data={message} />
-					)
+				{hasMessages && (
+					<div className="filters">
+						{Object.keys(variantSeverity).map((variant) => (
+							<button
+								key={variant}
+								className={`clear -${variant} ${filter.variants?.includes(variant) ? '-active' : ''}`}
+								onClick={() => toggleVariantFilter(variant)}
+								title={`Only show messages with ${variant} notes`}
+							>
+								{icons[variant]}
+							</button>
+						))}
+						<button
+							className={`clear ${filter.modified ? '-active' : ''}`}
+							onClick={() => setFilter({...filter, modified: !filter.modified})}
+							title="Only show modified files"
+						>
+							modified
+						</button>
+						<input
+							type="search"
+							value={filter.path || ''}
+							onChange={(event) => setFilter({...filter, path: event.currentTarget.value})}
+							placeholder="Filter by path"
+						/>
+					</div>
+				)}
+				{page?.messages.map((stored, i) => (
+					<Fragment key={stored.index}>
+						{i > 0 && stored.run !== page.messages[i - 1].run && <hr />}
+						{stored.message && <Message data={stored.message} />}
+					</Fragment>
+				))}
+				{page && page.messages.length < page.total && (
+					<button className="more" onClick={() => setLimit(limit + pageSize)}>
+						Show more ({page.total - page.messages.length} hidden)
+					</button>
 				)}
 			</section>
 
@@ -130,6 +238,18 @@ function App() {
 						directory they're in.
 					</p>
 				</div>
+
+				<div
+					className="dropzone watch"
+					ref={watchDropzoneRef}
+					style={{'--wails-drop-target': 'drop'} as React.CSSProperties}
+				>
+					<h3>Watch Package</h3>
+					<p>
+						Drop a package directory inside <code>AddonPackagesBuilder/</code> to fix files in it every time
+						they change.
+					</p>
+				</div>
 			</section>
 
 			<div className="actions -left">
@@ -140,16 +260,133 @@ function App() {
 				>
 					{icons.circleFull}
 				</button>
-				{messages.length > 0 && (
-					<button className="clear" onClick={() => setMessages([])} title="Clear output history">
+				{profiles.length > 1 && (
+					<select
+						value={config.profile || 'default'}
+						onChange={(event) => SwitchProfile(event.currentTarget.value).catch(console.error)}
+						title="Switch config profile"
+					>
+						{profiles.map((name) => (
+							<option key={name} value={name}>
+								{name}
+							</option>
+						))}
+					</select>
+				)}
+				{fixers.map((fixer) => (
+					<button
+						key={fixer.name}
+						className={`clear ${disabledFixers.includes(fixer.name) ? '' : '-active'}`}
+						onClick={() => toggleFixer(fixer.name)}
+						title={`${fixer.description}\nRuns in: ${fixer.modes.join(', ')}`}
+					>
+						{fixer.name}
+					</button>
+				))}
+				<button
+					className={`clear ${force ? '-active' : ''}`}
+					onClick={() => setForce(!force)}
+					title="Process all files, even those that didn't change since they were last validated"
+				>
+					force
+				</button>
+				<button
+					className="clear"
+					onClick={() => RunJob().catch(console.error)}
+					title="Run steps of a job file, e.g. a whole release procedure"
+				>
+					Job
+				</button>
+				{watches.map((path) => (
+					<button
+						key={path}
+						className="clear -active"
+						onClick={() => StopWatch(path)}
+						title={`Watching ${path}\nClick to stop`}
+					>
+						{path.split('/').at(-1)}
+					</button>
+				))}
+				{history.length > 0 && (
+					<select
+						value=""
+						onChange={(event) => openHistoryRun(event.currentTarget.value)}
+						title="Show messages of a previous run"
+					>
+						<option value="" disabled>
+							History
+						</option>
+						{history.map((entry) => (
+							<option key={entry.id} value={entry.id}>
+								{entry.summary && historyLabel(entry.summary)}
+							</option>
+						))}
+					</select>
+				)}
+				{hasMessages && (
+					<button
+						className="clear"
+						onClick={() => RerunLast().catch(console.error)}
+						title="Run the last operation again on the same paths"
+					>
+						Re-run
+					</button>
+				)}
+				{hasMessages && (
+					<button
+						className="clear"
+						onClick={() => RerunFailed().catch(console.error)}
+						title="Run the last operation again only on files with errors or warnings"
+					>
+						Re-run failed
+					</button>
+				)}
+				{hasMessages && (
+					<button
+						className="clear"
+						onClick={() => ExportReport().catch(console.error)}
+						title="Export messages of the last run as HTML, Markdown, JSON, SARIF, or JUnit XML"
+					>
+						Export
+					</button>
+				)}
+				{hasMessages && (
+					<button className="clear" onClick={() => ClearMessages()} title="Clear output history">
 						Clear
 					</button>
 				)}
 			</div>
+
+			{progress && (
+				<div className="actions -right">
+					<span className="progress" title={progress.current}>
+						{progress.operation}: {progress.scanned} scanned, {progress.matched} matched,{' '}
+						{progress.modified} modified
+					</span>
+					<button className="clear" onClick={() => Cancel()} title="Cancel running operations">
+						Cancel
+					</button>
+				</div>
+			)}
 		</main>
 	);
 }
 
+function historyLabel(summary: lib.Summary) {
+	const started = new Date(summary.started).toLocaleString();
+	const roots = summary.roots.map((root) => root.split('/').at(-1)).join(', ');
+	return `${started} ${summary.operation}: ${roots}`;
+}
+
+type Progress = {
+	operation: string;
+	scanned: number;
+	matched: number;
+	modified: number;
+	current: string;
+	done: boolean;
+};
+
 const variantSeverity: Record<string, number> = {
 	info: 1,
 	warning: 2,
@@ -202,10 +439,14 @@ function Note({data}: {data: lib.Note}) {
 		<li className={`-${data.variant} ${showDetails ? '-expanded' : ''}`}>
 			<header onClick={hasDetails ? () => setShowDetails(!showDetails) : undefined}>
 				{icons[data.variant]}
-				<h2
-					dangerouslySetInnerHTML={{__html: data.text}}
-					title={hasDetails && (showDetails ? 'Hide details' : 'Show details')}
-				/>
+				<h2 title={hasDetails && (showDetails ? 'Hide details' : 'Show details')}>
+					{data.segments ? data.segments.map((segment, i) => <Segment key={i} data={segment} />) : data.text}
+				</h2>
+				{data.code && (
+					<small className="code" title={[data.fixer, data.rule, data.path].filter(Boolean).join(' · ')}>
+						{data.code}
+					</small>
+				)}
 				{hasDetails && (showDetails ? icons.arrowUp : icons.arrowDown)}
 			</header>
 			{showDetails && (
@@ -217,6 +458,18 @@ function Note({data}: {data: lib.Note}) {
 	);
 }
 
+function Segment({data}: {data: lib.Segment}) {
+	switch (data.kind) {
+		case 'code':
+			return <code>{data.text}</code>;
+		case 'emphasis':
+			return <b>{data.text}</b>;
+		case 'path':
+			return <code className="path">{data.text}</code>;
+	}
+	return <>{data.text}</>;
+}
+
 const icons: Record<string, JSX.Element> = {
 	success: (
 		<svg
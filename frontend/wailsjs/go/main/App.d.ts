@@ -2,14 +2,64 @@
 // This file is automatically generated. DO NOT EDIT
 import {lib} from '../models';
 
+export function Cancel():Promise<void>;
+
+export function ClearMessages():Promise<void>;
+
+export function DeleteProfile(arg1:string):Promise<void>;
+
 export function Dummy():Promise<lib.Message>;
 
+export function DuplicateProfile(arg1:string,arg2:string):Promise<void>;
+
+export function ExportProfile(arg1:string):Promise<string>;
+
+export function ExportReport():Promise<string>;
+
 export function FixItemsGender(arg1:Array<string>):Promise<void>;
 
 export function FixPaths(arg1:Array<string>):Promise<void>;
 
 export function GetConfig():Promise<lib.AppConfig>;
 
+export function ImportProfile():Promise<string>;
+
 export function InitPaths(arg1:Array<string>):Promise<void>;
 
+export function ListFixers():Promise<Array<lib.FixerInfo>>;
+
+export function ListHistory():Promise<Array<lib.HistoryEntry>>;
+
+export function ListProfiles():Promise<Array<string>>;
+
+export function ListRuns():Promise<Array<lib.StoredRun>>;
+
+export function ListWatches():Promise<Array<string>>;
+
+export function LoadHistoryRun(arg1:string):Promise<lib.HistoryRun>;
+
+export function OpenHistoryRun(arg1:string):Promise<lib.StoredRun>;
+
+export function PruneHistory(arg1:number,arg2:number):Promise<number>;
+
+export function QueryMessages(arg1:lib.MessageQuery):Promise<lib.MessagePage>;
+
+export function RerunFailed():Promise<void>;
+
+export function RerunLast():Promise<void>;
+
+export function RunJob():Promise<string>;
+
+export function RunJobFile(arg1:string):Promise<void>;
+
+export function RunPaths(arg1:string,arg2:Array<string>,arg3:lib.RunOptions):Promise<void>;
+
+export function SearchHistory(arg1:string):Promise<Array<lib.HistoryMatch>>;
+
 export function SetConfig(arg1:lib.AppConfig):Promise<void>;
+
+export function StartWatch(arg1:string):Promise<void>;
+
+export function StopWatch(arg1:string):Promise<void>;
+
+export function SwitchProfile(arg1:string):Promise<void>;
@@ -2,10 +2,34 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function Cancel() {
+  return window['go']['main']['App']['Cancel']();
+}
+
+export function ClearMessages() {
+  return window['go']['main']['App']['ClearMessages']();
+}
+
+export function DeleteProfile(arg1) {
+  return window['go']['main']['App']['DeleteProfile'](arg1);
+}
+
 export function Dummy() {
   return window['go']['main']['App']['Dummy']();
 }
 
+export function DuplicateProfile(arg1, arg2) {
+  return window['go']['main']['App']['DuplicateProfile'](arg1, arg2);
+}
+
+export function ExportProfile(arg1) {
+  return window['go']['main']['App']['ExportProfile'](arg1);
+}
+
+export function ExportReport() {
+  return window['go']['main']['App']['ExportReport']();
+}
+
 export function FixItemsGender(arg1) {
   return window['go']['main']['App']['FixItemsGender'](arg1);
 }
@@ -18,10 +42,86 @@ export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
 
+export function ImportProfile() {
+  return window['go']['main']['App']['ImportProfile']();
+}
+
 export function InitPaths(arg1) {
   return window['go']['main']['App']['InitPaths'](arg1);
 }
 
+export function ListFixers() {
+  return window['go']['main']['App']['ListFixers']();
+}
+
+export function ListHistory() {
+  return window['go']['main']['App']['ListHistory']();
+}
+
+export function ListProfiles() {
+  return window['go']['main']['App']['ListProfiles']();
+}
+
+export function ListRuns() {
+  return window['go']['main']['App']['ListRuns']();
+}
+
+export function ListWatches() {
+  return window['go']['main']['App']['ListWatches']();
+}
+
+export function LoadHistoryRun(arg1) {
+  return window['go']['main']['App']['LoadHistoryRun'](arg1);
+}
+
+export function OpenHistoryRun(arg1) {
+  return window['go']['main']['App']['OpenHistoryRun'](arg1);
+}
+
+export function PruneHistory(arg1, arg2) {
+  return window['go']['main']['App']['PruneHistory'](arg1, arg2);
+}
+
+export function QueryMessages(arg1) {
+  return window['go']['main']['App']['QueryMessages'](arg1);
+}
+
+export function RerunFailed() {
+  return window['go']['main']['App']['RerunFailed']();
+}
+
+export function RerunLast() {
+  return window['go']['main']['App']['RerunLast']();
+}
+
+export function RunJob() {
+  return window['go']['main']['App']['RunJob']();
+}
+
+export function RunJobFile(arg1) {
+  return window['go']['main']['App']['RunJobFile'](arg1);
+}
+
+export function RunPaths(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RunPaths'](arg1, arg2, arg3);
+}
+
+export function SearchHistory(arg1) {
+  return window['go']['main']['App']['SearchHistory'](arg1);
+}
+
 export function SetConfig(arg1) {
   return window['go']['main']['App']['SetConfig'](arg1);
 }
+
+export function StartWatch(arg1) {
+  return window['go']['main']['App']['StartWatch'](arg1);
+}
+
+export function StopWatch(arg1) {
+  return window['go']['main']['App']['StopWatch'](arg1);
+}
+
+export function SwitchProfile(arg1) {
+  return window['go']['main']['App']['SwitchProfile'](arg1);
+}
@@ -1,7 +1,16 @@
 export namespace lib {
 	
 	export class AppConfig {
+	    version: number;
 	    onTop: boolean;
+	    workers: number;
+	    disabled?: Record<string, Array<string>>;
+	    severity?: Record<string, string>;
+	    include: string[];
+	    exclude: string[];
+	    followSymlinks: boolean;
+	    namespaceVersion?: string;
+	    profile?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppConfig(source);
@@ -9,12 +18,26 @@ export namespace lib {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
 	        this.onTop = source["onTop"];
+	        this.workers = source["workers"];
+	        this.disabled = source["disabled"];
+	        this.severity = source["severity"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.followSymlinks = source["followSymlinks"];
+	        this.namespaceVersion = source["namespaceVersion"];
+	        this.profile = source["profile"];
 	    }
 	}
 	export class Note {
 	    variant: string;
+	    rule?: string;
+	    code?: string;
+	    fixer?: string;
+	    path?: string;
 	    text: string;
+	    segments?: Segment[];
 	    details?: string;
 	
 	    static createFrom(source: any = {}) {
@@ -24,14 +47,100 @@ export namespace lib {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.variant = source["variant"];
+	        this.rule = source["rule"];
+	        this.code = source["code"];
+	        this.fixer = source["fixer"];
+	        this.path = source["path"];
 	        this.text = source["text"];
+	        this.segments = this.convertValues(source["segments"], Segment);
 	        this.details = source["details"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Summary {
+	    operation: string;
+	    roots: string[];
+	    // Go type: time
+	    started: any;
+	    // Go type: time
+	    finished: any;
+	    scanned: number;
+	    processed: number;
+	    modified: number;
+	    skipped: number;
+	    unchanged: number;
+	    ignored: number;
+	    pruned: string[];
+	    configs: string[];
+	    variants: Record<string, number>;
+	    fixers: Record<string, number>;
+	    problems: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.operation = source["operation"];
+	        this.roots = source["roots"];
+	        this.started = this.convertValues(source["started"], null);
+	        this.finished = this.convertValues(source["finished"], null);
+	        this.scanned = source["scanned"];
+	        this.processed = source["processed"];
+	        this.modified = source["modified"];
+	        this.skipped = source["skipped"];
+	        this.unchanged = source["unchanged"];
+	        this.ignored = source["ignored"];
+	        this.pruned = source["pruned"];
+	        this.configs = source["configs"];
+	        this.variants = source["variants"];
+	        this.fixers = source["fixers"];
+	        this.problems = source["problems"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Message {
 	    icon?: string;
 	    title: string;
 	    notes: Note[];
+	    modified?: boolean;
+	    fixer?: string;
+	    summary?: Summary;
 	
 	    static createFrom(source: any = {}) {
 	        return new Message(source);
@@ -42,6 +151,318 @@ export namespace lib {
 	        this.icon = source["icon"];
 	        this.title = source["title"];
 	        this.notes = this.convertValues(source["notes"], Note);
+	        this.modified = source["modified"];
+	        this.fixer = source["fixer"];
+	        this.summary = this.convertValues(source["summary"], Summary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FixerInfo {
+	    name: string;
+	    description: string;
+	    modes: string[];
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FixerInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.modes = source["modes"];
+	        this.version = source["version"];
+	    }
+	}
+	export class RunOptions {
+	    fixers: string[];
+	    force: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RunOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fixers = source["fixers"];
+	        this.force = source["force"];
+	    }
+	}
+	export class Segment {
+	    kind: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Segment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.text = source["text"];
+	    }
+	}
+	export class HistoryEntry {
+	    id: string;
+	    summary?: Summary;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.summary = this.convertValues(source["summary"], Summary);
+	        this.size = source["size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryMatch {
+	    entry?: HistoryEntry;
+	    messages: Message[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entry = this.convertValues(source["entry"], HistoryEntry);
+	        this.messages = this.convertValues(source["messages"], Message);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryRun {
+	    id: string;
+	    summary?: Summary;
+	    messages: Message[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.summary = this.convertValues(source["summary"], Summary);
+	        this.messages = this.convertValues(source["messages"], Message);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MessageFilter {
+	    variants?: string[];
+	    fixers?: string[];
+	    path?: string;
+	    modified?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessageFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.variants = source["variants"];
+	        this.fixers = source["fixers"];
+	        this.path = source["path"];
+	        this.modified = source["modified"];
+	    }
+	}
+	export class MessageQuery {
+	    run: number;
+	    filter: MessageFilter;
+	    offset: number;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessageQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.run = source["run"];
+	        this.filter = this.convertValues(source["filter"], MessageFilter);
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StoredMessage {
+	    index: number;
+	    run: number;
+	    message?: Message;
+	
+	    static createFrom(source: any = {}) {
+	        return new StoredMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.run = source["run"];
+	        this.message = this.convertValues(source["message"], Message);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MessagePage {
+	    messages: StoredMessage[];
+	    total: number;
+	    offset: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessagePage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.messages = this.convertValues(source["messages"], StoredMessage);
+	        this.total = source["total"];
+	        this.offset = source["offset"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StoredRun {
+	    id: number;
+	    operation: string;
+	    roots: string[];
+	    // Go type: time
+	    started: any;
+	    count: number;
+	    done: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StoredRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.operation = source["operation"];
+	        this.roots = source["roots"];
+	        this.started = this.convertValues(source["started"], null);
+	        this.count = source["count"];
+	        this.done = source["done"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
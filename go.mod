@@ -6,6 +6,7 @@ toolchain go1.23.1
 
 require (
 	github.com/adrg/xdg v0.5.0
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/tidwall/gjson v1.9.3
 	github.com/tidwall/pretty v1.2.0
 	github.com/tidwall/sjson v1.1.7
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"app/lib"
+)
+
+// Paths passed when launching the app, e.g. by "Open with" or a shortcut, and the operation to run on them.
+type launchRequest struct {
+	operation lib.Operation
+	paths     []string
+}
+
+// Parses `[--init|--fix|--gender] <paths...>` launch arguments. Each flag applies to paths after it,
+// paths before any flag are fixed. Relative paths are resolved against workingDir.
+// Returns the requests in order along with arguments that weren't understood.
+func parseLaunchArgs(args []string, workingDir string) (requests []launchRequest, unknown []string) {
+	operation := lib.OpFix
+	flagsDone := false
+
+	for _, arg := range args {
+		// Process serial number macOS passes to apps started from Finder
+		if !flagsDone && strings.HasPrefix(arg, "-psn_") {
+			continue
+		}
+		if !flagsDone && strings.HasPrefix(arg, "-") {
+			if arg == "--" {
+				flagsDone = true
+				continue
+			}
+			op, err := lib.ParseOperation(strings.TrimLeft(arg, "-"))
+			if err != nil {
+				unknown = append(unknown, arg)
+				continue
+			}
+			operation = op
+			continue
+		}
+
+		if !filepath.IsAbs(arg) && workingDir != "" {
+			arg = filepath.Join(workingDir, arg)
+		}
+		if n := len(requests); n > 0 && requests[n-1].operation == operation {
+			requests[n-1].paths = append(requests[n-1].paths, arg)
+		} else {
+			requests = append(requests, launchRequest{operation: operation, paths: []string{arg}})
+		}
+	}
+
+	return requests, unknown
+}
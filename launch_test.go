@@ -0,0 +1,25 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"app/lib"
+)
+
+func TestParseLaunchArgs(t *testing.T) {
+	dir := filepath.FromSlash("/work")
+	requests, unknown := parseLaunchArgs([]string{"-psn_0_12345", "a", "--gender", "b", "--bogus", "--", "--fix"}, dir)
+
+	want := []launchRequest{
+		{operation: lib.OpFix, paths: []string{filepath.Join(dir, "a")}},
+		{operation: lib.OpGender, paths: []string{filepath.Join(dir, "b"), filepath.Join(dir, "--fix")}},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests %+v, want %+v", requests, want)
+	}
+	if !reflect.DeepEqual(unknown, []string{"--bogus"}) {
+		t.Errorf("unknown arguments %v, want only --bogus", unknown)
+	}
+}
@@ -0,0 +1,170 @@
+package lib
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Name of the package manifest VaM requires in the root of every .var file.
+const PackageMetaFileName = "meta.json"
+
+// Default location of a built package, `AddonPackages/<package>.var` next to `AddonPackagesBuilder`.
+func DefaultPackageOutput(root string) string {
+	root = filepath.ToSlash(filepath.Clean(root))
+	return path.Join(path.Dir(path.Dir(root)), "AddonPackages", path.Base(root))
+}
+
+// Packs a package directory in AddonPackagesBuilder into a .var archive, skipping files excluded by
+// config, project config, and .cpuignore files. Include patterns don't apply, they select files to
+// fix. An existing archive is replaced only once the new one is complete. Ends by reporting
+// a summary message, which is also returned.
+func BuildPackage(ctx context.Context, reporter Reporter, root string, output string, config *AppConfig) *Summary {
+	root = filepath.ToSlash(filepath.Clean(root))
+	output = filepath.ToSlash(filepath.Clean(output))
+	summary := NewSummary("build", []string{root})
+	emit := func(message *Message) {
+		summary.Add(message)
+		reporter.Message(message)
+	}
+	finish := func(scanned int, processed int) *Summary {
+		summary.Finish(scanned, processed)
+		reporter.Message(summary.Message())
+		reporter.Progress(&Progress{Operation: "build", Scanned: scanned, Matched: processed, Modified: summary.Modified, Done: true})
+		return summary
+	}
+
+	if packageRoot, ok := GetPackageRoot(root); !ok || !strings.EqualFold(packageRoot, root) || !fileExists(root) {
+		emit(&Message{Icon: Ptr("file"), Title: root, Notes: []Note{{
+			Variant: "danger", Rule: "not-package", Code: "VAR001", Text: "Only package directories in AddonPackagesBuilder can be built.",
+		}}})
+		return finish(0, 0)
+	}
+	if !fileExists(path.Join(root, PackageMetaFileName)) {
+		emit(&Message{Icon: Ptr("file"), Title: root, Notes: []Note{Note{
+			Variant: "danger", Rule: "meta-missing", Code: "VAR002",
+		}.WithSegments(Plain("Package has no "), Code(PackageMetaFileName), Plain(", VaM won't load it."))}})
+		return finish(0, 0)
+	}
+
+	projects := NewProjectConfigs(config)
+	walker := newWalker(ctx, config, projects)
+	walker.skipInclude = true
+	var files []string
+	visit := func(filePath string) error {
+		name := path.Base(filePath)
+		if name != IgnoreFileName && !strings.EqualFold(filePath, path.Join(root, ProjectConfigFileName)) {
+			files = append(files, filePath)
+		}
+		return nil
+	}
+	var walkErrors []*Message
+	// Warnings, like skipped invalid ignore patterns, don't prevent the build
+	readFailed := false
+	onError := func(filePath string, err error) {
+		message := walkErrorMessage(filePath, err)
+		readFailed = readFailed || slices.ContainsFunc(message.Notes, func(note Note) bool { return note.Variant == Error })
+		walkErrors = append(walkErrors, message)
+	}
+	walkErr := walker.walk(root, visit, onError)
+	summary.Pruned = append(summary.Pruned, walker.pruned...)
+	summary.Ignored = walker.ignored
+	summary.Configs = projects.Applied()
+
+	for _, message := range walkErrors {
+		emit(message)
+	}
+	if err := ctx.Err(); err != nil {
+		emit(cancelledMessage(output, "VAR005", err))
+		return finish(len(files), 0)
+	}
+	if walkErr != nil || readFailed {
+		emit(&Message{Icon: Ptr("file"), Title: output, Notes: []Note{{
+			Variant: "danger", Rule: "build-failed", Code: "VAR003", Text: "Not all package files could be read, package wasn't built.",
+		}}})
+		return finish(len(files), 0)
+	}
+
+	if err := writePackage(ctx, root, output, files); err != nil {
+		if ctx.Err() != nil {
+			emit(cancelledMessage(output, "VAR005", err))
+		} else {
+			emit(&Message{Icon: Ptr("file"), Title: output, Notes: []Note{{
+				Variant: "danger", Rule: "build-failed", Code: "VAR003", Text: "Couldn't write package.", Details: Ptr(err.Error()),
+			}}})
+		}
+		return finish(len(files), 0)
+	}
+
+	relative := make([]string, len(files))
+	for i, filePath := range files {
+		relative[i], _ = relativeTo(root, filePath)
+	}
+	emit(&Message{Icon: Ptr("file"), Title: output, Modified: true, Notes: []Note{Note{
+		Variant: "success", Rule: "package-built", Code: "VAR004", Details: Ptr(strings.Join(relative, "\n")),
+	}.WithSegments(Plain(fmt.Sprintf("Packed %d files from ", len(files))), PathSegment(path.Base(root)), Plain("."))}})
+	return finish(len(files), len(files))
+}
+
+// Writes files into a zip archive next to output, and moves it over output when done.
+func writePackage(ctx context.Context, root string, output string, files []string) error {
+	if err := os.MkdirAll(path.Dir(output), 0755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(path.Dir(output), path.Base(output)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	archive := zip.NewWriter(temp)
+	for _, filePath := range files {
+		if err := ctx.Err(); err != nil {
+			temp.Close()
+			return err
+		}
+		if err := addToArchive(archive, root, filePath); err != nil {
+			temp.Close()
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), output)
+}
+
+func addToArchive(archive *zip.Writer, root string, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name, _ = relativeTo(root, filePath)
+	header.Method = zip.Deflate
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, file)
+	return err
+}
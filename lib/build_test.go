@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"archive/zip"
+	"context"
+	"path"
+	"slices"
+	"testing"
+)
+
+func TestBuildPackageWithIgnoreWarnings(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"AddonPackagesBuilder/Me.Pkg.1.var/meta.json":         `{}`,
+		"AddonPackagesBuilder/Me.Pkg.1.var/.cpuignore":        "[z-a]\n*.psd\n",
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Item.vaj":   `{}`,
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Source.psd": "",
+	})
+	pkg := path.Join(root, "AddonPackagesBuilder/Me.Pkg.1.var")
+	output := DefaultPackageOutput(pkg)
+
+	memory := NewMemoryReporter()
+	summary := BuildPackage(context.Background(), memory, pkg, output, NewAppConfig())
+
+	if !hasCode(memory.Messages(), "CPU018") || !hasCode(memory.Messages(), "VAR004") {
+		t.Fatalf("invalid ignore pattern wasn't a warning, or package wasn't built, %d errors", summary.Variants[Error])
+	}
+	archive, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	slices.Sort(names)
+	if want := []string{"Custom/Item.vaj", "meta.json"}; !slices.Equal(names, want) {
+		t.Errorf("package contains %v, want %v", names, want)
+	}
+}
@@ -3,36 +3,168 @@ package lib
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"slices"
+	"time"
 )
 
+// Config file exists but can't be used, either because it can't be parsed or has invalid values.
+var ErrCorruptConfig = errors.New("corrupt config file")
+
+// Config file was written by a newer version of the app. It's left in place and isn't saved over.
+var ErrNewerConfig = errors.New("config file is from a newer version")
+
+// Migrates raw config data from one schema version to the next.
+type Migration func(data map[string]interface{}) error
+
+// Implemented by configs that can check their own values after loading.
+type Validator interface {
+	Validate() error
+}
+
 type ConfigStore struct {
 	Path string
+	// Current schema version stored in the "version" property. 0 disables versioning.
+	Version int
+	// Migrations indexed by the version they migrate from.
+	Migrations map[int]Migration
+	// Modification time of the file when it was last loaded or saved successfully. Not updated when
+	// loading fails, so the file is still considered changed on disk and isn't saved over.
+	modTime time.Time
 }
 
 func NewConfigStore(path string) *ConfigStore {
 	return &ConfigStore{Path: path}
 }
 
+func NewVersionedConfigStore(path string, version int, migrations map[int]Migration) *ConfigStore {
+	return &ConfigStore{Path: path, Version: version, Migrations: migrations}
+}
+
 func (c *ConfigStore) Load(v interface{}) error {
+	modTime := c.currentModTime()
 	data, err := os.ReadFile(c.Path)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
+			c.modTime = time.Time{}
 			return nil
 		}
 		return err
 	}
 
+	if c.Version > 0 {
+		data, err = c.migrate(data)
+		if errors.Is(err, ErrNewerConfig) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrCorruptConfig, err)
+		}
+	}
+
 	err = json.Unmarshal(data, v)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrCorruptConfig, err)
+	}
+
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrCorruptConfig, err)
+		}
 	}
 
+	c.modTime = modTime
 	return nil
 }
 
+// Loads the config, and when it's corrupt, moves it to `*.bad` so the app can continue with
+// defaults. Returns the path the corrupt file was moved to along with the error that caused it.
+func (c *ConfigStore) LoadOrRecover(v interface{}) (badPath string, err error) {
+	err = c.Load(v)
+	if err == nil || !errors.Is(err, ErrCorruptConfig) {
+		return "", err
+	}
+	return c.moveAside(err)
+}
+
+// Renames the config file to `*.bad`, overwriting any previous one.
+func (c *ConfigStore) moveAside(cause error) (badPath string, err error) {
+	badPath = c.Path + ".bad"
+	if renameErr := os.Rename(c.Path, badPath); renameErr != nil {
+		return "", fmt.Errorf("%w, and it couldn't be moved away: %w", cause, renameErr)
+	}
+	c.modTime = time.Time{}
+	return badPath, cause
+}
+
+// Runs all migrations from the version in data to the current one.
+func (c *ConfigStore) migrate(data []byte) ([]byte, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	version, err := parseConfigVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+	if version > c.Version {
+		return nil, fmt.Errorf("%w: version %d, supported is %d", ErrNewerConfig, version, c.Version)
+	}
+	if version == c.Version {
+		return data, nil
+	}
+
+	for ; version < c.Version; version++ {
+		if migrate, ok := c.Migrations[version]; ok {
+			if err := migrate(raw); err != nil {
+				return nil, fmt.Errorf("migration from version %d failed: %w", version, err)
+			}
+		}
+	}
+	raw["version"] = c.Version
+
+	return json.Marshal(raw)
+}
+
+// Version in the "version" property of raw config data, 0 when it has none.
+func parseConfigVersion(raw map[string]interface{}) (int, error) {
+	value, ok := raw["version"]
+	if !ok {
+		return 0, nil
+	}
+	number, ok := value.(float64)
+	if !ok || number != float64(int(number)) || number < 0 {
+		return 0, fmt.Errorf("invalid version %v", value)
+	}
+	return int(number), nil
+}
+
+// Whether the file on disk has a newer schema version than the store supports.
+func (c *ConfigStore) newerOnDisk() bool {
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return false
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return false
+	}
+	version, err := parseConfigVersion(raw)
+	return err == nil && version > c.Version
+}
+
+// Saves the config, unless the file on disk is from a newer version, see `ErrNewerConfig`.
 func (c *ConfigStore) Save(v interface{}) error {
+	if c.Version > 0 && c.newerOnDisk() {
+		return fmt.Errorf("%w, not saving over it", ErrNewerConfig)
+	}
+
 	data, err := JSONMarshalPretty(v)
 	if err != nil {
 		return err
@@ -43,6 +175,71 @@ func (c *ConfigStore) Save(v interface{}) error {
 		return err
 	}
 
+	c.modTime = c.currentModTime()
+	return nil
+}
+
+// Whether the file was changed by something else since it was last loaded or saved.
+func (c *ConfigStore) ChangedOnDisk() bool {
+	return !c.currentModTime().Equal(c.modTime)
+}
+
+// Zero when the file doesn't exist.
+func (c *ConfigStore) currentModTime() time.Time {
+	info, err := os.Stat(c.Path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Current AppConfig schema version.
+const AppConfigVersion = 1
+
+var appConfigMigrations = map[int]Migration{
+	// Configs from before versioning only had `onTop`, which is still valid.
+	0: func(data map[string]interface{}) error { return nil },
+}
+
+func NewAppConfigStore(path string) *ConfigStore {
+	return NewVersionedConfigStore(path, AppConfigVersion, appConfigMigrations)
+}
+
+func (c *AppConfig) Validate() error {
+	if c.Workers < 0 {
+		return fmt.Errorf("workers can't be negative, received %d", c.Workers)
+	}
+	for operation, names := range c.Disabled {
+		if _, err := ParseOperation(string(operation)); err != nil {
+			return fmt.Errorf("disabled: %w", err)
+		}
+		for _, name := range names {
+			if name == "" {
+				return fmt.Errorf("disabled: empty fixer/rule name in \"%s\"", operation)
+			}
+		}
+	}
+	for rule, variant := range c.Severity {
+		if !slices.Contains(Variants, variant) {
+			return fmt.Errorf("severity: unknown variant \"%s\" for rule \"%s\"", variant, rule)
+		}
+	}
+	if err := ValidateIgnorePatterns(c.Include); err != nil {
+		return fmt.Errorf("include: %w", err)
+	}
+	if err := ValidateIgnorePatterns(c.Exclude); err != nil {
+		return fmt.Errorf("exclude: %w", err)
+	}
+	switch c.NamespaceVersion {
+	case "", NamespaceLatest, NamespaceExact, NamespaceMin:
+	default:
+		return fmt.Errorf("unknown namespace version policy \"%s\"", c.NamespaceVersion)
+	}
+	if c.Profile != "" {
+		if err := ValidateProfileName(c.Profile); err != nil {
+			return fmt.Errorf("profile: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -78,8 +275,28 @@ func (ws *WindowStateStore) Set(name string, state *WindowState) *WindowState {
 
 func (ws *WindowStateStore) Load() error {
 	err := ws.store.Load(&ws.states)
-	if err != nil && errors.Is(err, fs.ErrNotExist) {
-		return err
+	if err == nil {
+		err = ws.validate()
+	}
+	return err
+}
+
+// Same as `ConfigStore.LoadOrRecover`, states are reset when the file is corrupt.
+func (ws *WindowStateStore) LoadOrRecover() (badPath string, err error) {
+	err = ws.Load()
+	if err == nil || !errors.Is(err, ErrCorruptConfig) {
+		return "", err
+	}
+
+	ws.states = map[string]*WindowState{}
+	return ws.store.moveAside(err)
+}
+
+func (ws *WindowStateStore) validate() error {
+	for name, state := range ws.states {
+		if state == nil || state.Width <= 0 || state.Height <= 0 {
+			return fmt.Errorf("%w: invalid state of window \"%s\"", ErrCorruptConfig, name)
+		}
 	}
 	return nil
 }
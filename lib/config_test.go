@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, data string) *ConfigStore {
+	t.Helper()
+	root := writeFiles(t, t.TempDir(), map[string]string{"config.json": data})
+	return NewAppConfigStore(path.Join(root, "config.json"))
+}
+
+func TestConfigStoreMigratesUnversioned(t *testing.T) {
+	store := writeConfig(t, `{"onTop": true}`)
+	config := NewAppConfig()
+	if err := store.Load(config); err != nil {
+		t.Fatal(err)
+	}
+	if !config.OnTop || config.Version != AppConfigVersion {
+		t.Errorf("loaded onTop %v, version %d, want true, %d", config.OnTop, config.Version, AppConfigVersion)
+	}
+}
+
+func TestConfigStoreRecoversCorrupt(t *testing.T) {
+	tests := map[string]string{
+		"syntax":          `{"onTop": `,
+		"invalid version": `{"version": "1"}`,
+		"invalid value":   `{"version": 1, "workers": -1}`,
+		"invalid pattern": `{"version": 1, "exclude": ["[z-a]"]}`,
+	}
+	for name, data := range tests {
+		store := writeConfig(t, data)
+		badPath, err := store.LoadOrRecover(NewAppConfig())
+		if !errors.Is(err, ErrCorruptConfig) {
+			t.Errorf("%s: error %v, want ErrCorruptConfig", name, err)
+			continue
+		}
+		if badPath != store.Path+".bad" {
+			t.Errorf("%s: moved to %q", name, badPath)
+		}
+		if _, err := os.Stat(store.Path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: corrupt file wasn't moved away", name)
+		}
+	}
+}
+
+func TestConfigStoreKeepsNewerVersion(t *testing.T) {
+	data := `{"version": 99, "future": true}`
+	store := writeConfig(t, data)
+
+	badPath, err := store.LoadOrRecover(NewAppConfig())
+	if !errors.Is(err, ErrNewerConfig) || errors.Is(err, ErrCorruptConfig) || badPath != "" {
+		t.Fatalf("got %q, %v, want ErrNewerConfig without moving the file", badPath, err)
+	}
+	if err := store.Save(NewAppConfig()); !errors.Is(err, ErrNewerConfig) {
+		t.Errorf("saved over newer config, error %v", err)
+	}
+	if saved, _ := os.ReadFile(store.Path); string(saved) != data {
+		t.Errorf("newer config was changed to %s", saved)
+	}
+}
+
+func TestConfigStoreChangedOnDiskAfterFailedLoad(t *testing.T) {
+	store := writeConfig(t, `{"version": 1}`)
+	if err := store.Load(NewAppConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if store.ChangedOnDisk() {
+		t.Fatal("changed on disk right after loading")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(store.Path, []byte(`{"version": 1, "workers": -1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(store.Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Load(NewAppConfig()); err == nil {
+		t.Fatal("loaded invalid config")
+	}
+	if !store.ChangedOnDisk() {
+		t.Error("external edit that failed to load isn't considered changed, it would be saved over")
+	}
+}
+
+func TestAppConfigValidate(t *testing.T) {
+	valid := NewAppConfig()
+	if err := valid.Validate(); err != nil {
+		t.Errorf("default config is invalid: %v", err)
+	}
+
+	tests := map[string]func(c *AppConfig){
+		"negative workers":  func(c *AppConfig) { c.Workers = -1 },
+		"unknown operation": func(c *AppConfig) { c.Disabled = map[Operation][]string{"nope": {"vaj"}} },
+		"empty rule":        func(c *AppConfig) { c.Disabled = map[Operation][]string{OpFix: {""}} },
+		"unknown variant":   func(c *AppConfig) { c.Severity = map[string]Variant{"vaj": "loud"} },
+		"invalid include":   func(c *AppConfig) { c.Include = []string{"[z-a]"} },
+		"namespace version": func(c *AppConfig) { c.NamespaceVersion = "newest" },
+	}
+	for name, change := range tests {
+		config := NewAppConfig()
+		change(config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: config is valid", name)
+		}
+	}
+}
+
+func TestConfigStoreKeepsClearedPatterns(t *testing.T) {
+	store := writeConfig(t, `{}`)
+	config := NewAppConfig()
+	config.Exclude = []string{}
+	if err := store.Save(config); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewAppConfig()
+	if err := store.Load(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Exclude) != 0 {
+		t.Errorf("cleared exclude patterns loaded as %v", loaded.Exclude)
+	}
+}
+
+func TestAppConfigClone(t *testing.T) {
+	config := NewAppConfig()
+	config.Disabled = map[Operation][]string{OpFix: {"vap"}}
+	config.Severity = map[string]Variant{"CPL001": Warning}
+	config.Include = []string{"Custom/"}
+
+	clone := config.Clone()
+	if !reflect.DeepEqual(clone, config) {
+		t.Fatalf("clone %+v differs from %+v", clone, config)
+	}
+	clone.Disabled[OpFix][0] = "cpl"
+	clone.Severity["CPL001"] = Error
+	clone.Include[0] = "x"
+	clone.Exclude[0] = "x"
+	if config.Disabled[OpFix][0] != "vap" || config.Severity["CPL001"] != Warning || config.Include[0] != "Custom/" || config.Exclude[0] != ".git/" {
+		t.Errorf("changing the clone changed the config: %+v", config)
+	}
+}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -19,24 +20,26 @@ import (
 var managerName = "Stopper.ClothingPluginManager"
 var managerPath = managerName + ".latest:/Custom/Scripts/Stopper/ClothingPluginManager/ClothingPluginManager.cs"
 
-func FixVaj(path string, fixOnly bool) *Message {
+func FixVaj(ctx context.Context, path string, fixOnly bool) *Message {
 	uid, err := getUID(path)
 	if err != nil {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "danger", Text: "Couldn't retrieve item's UID.", Details: Ptr(err.Error()),
+			Variant: "danger", Rule: "uid-missing", Code: "VAJ001", Text: "Couldn't retrieve item's UID.", Details: Ptr(err.Error()),
 		}}}
 	}
 
+	defer fileLocks.Lock(path)()
+
 	json, err := os.ReadFile(path)
 	if err != nil {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "danger", Text: "Couldn't read file.", Details: Ptr(err.Error()),
+			Variant: "danger", Rule: "read-failed", Code: "VAJ002", Text: "Couldn't read file.", Details: Ptr(err.Error()),
 		}}}
 	}
 
 	if !gjson.ValidBytes(json) {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "danger", Text: "Can't parse JSON (invalid).", Details: Ptr(string(json)),
+			Variant: "danger", Rule: "invalid-json", Code: "VAJ003", Text: "Can't parse JSON (invalid).", Details: Ptr(string(json)),
 		}}}
 	}
 
@@ -45,8 +48,15 @@ func FixVaj(path string, fixOnly bool) *Message {
 	storables := parsed.Get("storables")
 
 	if !components.Exists() || !components.IsArray() || !storables.Exists() || !storables.IsArray() {
+		invalidPath := "storables"
+		if !components.Exists() || !components.IsArray() {
+			invalidPath = "components"
+		}
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
 			Variant: "danger",
+			Rule:    "invalid-structure",
+			Code:    "VAJ004",
+			Path:    invalidPath,
 			Text:    "Invalid JSON.",
 			Details: Ptr("\"components\" or \"storables\" properties missing/invalid."),
 		}}}
@@ -58,12 +68,17 @@ func FixVaj(path string, fixOnly bool) *Message {
 
 	// Ensure manager component
 	// { "type": "MVRPluginManager" }
-	if components.Get(fmt.Sprintf("#(type==\"%s\").type", managerType)).Exists() {
-		notes = append(notes, Note{Variant: "info", Text: fmt.Sprintf("%s component already present.", managerType)})
+	componentItems := components.Array()
+	managerIndex := slices.IndexFunc(componentItems, func(component gjson.Result) bool {
+		return component.Get("type").String() == managerType
+	})
+	if managerIndex >= 0 {
+		notes = append(notes, Note{Variant: "info", Rule: "manager-component-present", Code: "VAJ005", Path: "components." + strconv.Itoa(managerIndex)}.
+			WithSegments(Code(managerType), Plain(" component already present.")))
 	} else {
 		if fixOnly {
 			return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-				Variant: "info", Text: "Manager not initialized in this file, skipping.",
+				Variant: "info", Rule: "manager-not-initialized", Code: "VAJ006", Path: "components", Text: "Manager not initialized in this file, skipping.",
 			}}}
 		}
 
@@ -72,12 +87,17 @@ func FixVaj(path string, fixOnly bool) *Message {
 		if err != nil {
 			return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
 				Variant: "danger",
+				Rule:    "manager-component-insert-failed",
+				Code:    "VAJ007",
+				Path:    "components",
 				Text:    "Couldn't insert manager component.",
 				Details: Ptr(err.Error()),
 			}}}
 		}
 
-		notes = append(notes, Note{Variant: "success", Text: fmt.Sprintf("Added %s component.", managerType)})
+		// Appended as the last component
+		notes = append(notes, Note{Variant: "success", Rule: "manager-component-added", Code: "VAJ008", Path: "components." + strconv.Itoa(len(componentItems))}.
+			WithSegments(Plain("Added "), Code(managerType), Plain(" component.")))
 		isModified = true
 	}
 
@@ -118,6 +138,9 @@ func FixVaj(path string, fixOnly bool) *Message {
 			if err != nil {
 				return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
 					Variant: "danger",
+					Rule:    "storable-insert-failed",
+					Code:    "VAJ009",
+					Path:    "storables.0",
 					Text:    "Couldn't insert plugins storable.",
 					Details: Ptr(err.Error()),
 				}}}
@@ -127,6 +150,9 @@ func FixVaj(path string, fixOnly bool) *Message {
 			if err != nil {
 				return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
 					Variant: "danger",
+					Rule:    "storable-insert-failed",
+					Code:    "VAJ009",
+					Path:    "storables",
 					Text:    "Couldn't insert plugins storable.",
 					Details: Ptr(err.Error()),
 				}}}
@@ -135,6 +161,9 @@ func FixVaj(path string, fixOnly bool) *Message {
 
 		notes = append(notes, Note{
 			Variant: "success",
+			Rule:    "storable-added",
+			Code:    "VAJ010",
+			Path:    "storables.0",
 			Text:    "Added plugins storable.",
 			Details: Ptr(JSONMarshalLog(data)),
 		})
@@ -146,7 +175,7 @@ func FixVaj(path string, fixOnly bool) *Message {
 		storablePlugins, isAMap := storable.Get("plugins").Value().(map[string]interface{})
 
 		if storableId == uid && storableManagerPath == managerPath && isAMap && len(storablePlugins) == 1 {
-			notes = append(notes, Note{Variant: "info", Text: "Storable ID & manager path are correct."})
+			notes = append(notes, Note{Variant: "info", Rule: "storable-ok", Code: "VAJ011", Path: "storables." + strconv.Itoa(storableIndex), Text: "Storable ID & manager path are correct."})
 		} else {
 			old := string(pretty.Pretty([]byte(parsed.Get("storables." + strconv.Itoa(storableIndex)).String())))
 			data := map[string]interface{}{
@@ -158,6 +187,9 @@ func FixVaj(path string, fixOnly bool) *Message {
 			if err != nil {
 				return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
 					Variant: "danger",
+					Rule:    "storable-replace-failed",
+					Code:    "VAJ012",
+					Path:    "storables." + strconv.Itoa(storableIndex),
 					Text:    "Couldn't replace old storable.",
 					Details: Ptr(err.Error()),
 				}}}
@@ -166,6 +198,9 @@ func FixVaj(path string, fixOnly bool) *Message {
 			new := parsed.Get("storables." + strconv.Itoa(storableIndex)).String()
 			notes = append(notes, Note{
 				Variant: "success",
+				Rule:    "storable-fixed",
+				Code:    "VAJ013",
+				Path:    "storables." + strconv.Itoa(storableIndex),
 				Text:    "Fixed storable ID/path.",
 				Details: Ptr(fmt.Sprintf("OLD:\n%v\n\nNEW:\n%s", old, new)),
 			})
@@ -174,102 +209,130 @@ func FixVaj(path string, fixOnly bool) *Message {
 	}
 
 	if isModified {
+		if err := ctx.Err(); err != nil {
+			return cancelledMessage(path, "VAJ016", err)
+		}
+
 		jsonPretty := pretty.PrettyOptions(json, &pretty.Options{Indent: "\t"})
 		err = os.WriteFile(path, jsonPretty, 0644)
 		if err != nil {
 			notes = append(notes, Note{
 				Variant: "danger",
+				Rule:    "write-failed",
+				Code:    "VAJ014",
 				Text:    "Couldn't write .vaj file.",
 				Details: Ptr(err.Error()),
 			})
+			isModified = false
 		} else {
 			notes = append(notes, Note{
 				Variant: "success",
+				Rule:    "file-saved",
+				Code:    "VAJ015",
 				Text:    "File saved.",
 				Details: Ptr(string(pretty.Pretty(json))),
 			})
 		}
 	}
 
-	return &Message{Icon: Ptr("file"), Title: path, Notes: notes}
+	return &Message{Icon: Ptr("file"), Title: path, Notes: notes, Modified: isModified}
 }
 
-func FixCpl(path string) *Message {
-	_, packageName, _, isPrepped := getPreppedPackageName(path)
+func FixCpl(ctx context.Context, path string, namespaceVersion string) *Message {
+	_, packageName, packageVersion, isPrepped := getPreppedPackageName(path)
 	if !isPrepped {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "info", Text: "Not in release prep mode, no changes necessary.",
+			Variant: "info", Rule: "not-prepped", Code: "CPL001", Text: "Not in release prep mode, no changes necessary.",
 		}}}
 	}
 
-	packageNamespace := packageName + ".latest"
+	packageNamespace := getPackageNamespace(packageName, packageVersion, namespaceVersion)
+
+	defer fileLocks.Lock(path)()
 
 	json, err := os.ReadFile(path)
 	if err != nil {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "danger", Text: "Couldn't read file.", Details: Ptr(err.Error()),
+			Variant: "danger", Rule: "read-failed", Code: "CPL002", Text: "Couldn't read file.", Details: Ptr(err.Error()),
 		}}}
 	}
 
 	newJson := namespaceCustomPaths(json, packageNamespace)
+	isModified := false
 	notes := []Note{}
 
 	if len(newJson) != len(json) {
 		notes = append(notes, Note{
 			Variant: "success",
+			Rule:    "paths-namespaced",
+			Code:    "CPL003",
 			Text:    "Namespaced custom paths to package name.",
 			Details: Ptr(fmt.Sprintf("Local \"Custom/*\" and \"SELF:/\" paths in plugin's storables have been namespaced to \"%s:/\".", packageNamespace)),
 		})
 
+		if err := ctx.Err(); err != nil {
+			return cancelledMessage(path, "CPL007", err)
+		}
+
 		jsonPretty := pretty.PrettyOptions(newJson, &pretty.Options{Indent: "\t"})
 		err = os.WriteFile(path, jsonPretty, 0644)
 		if err != nil {
 			notes = append(notes, Note{
 				Variant: "danger",
+				Rule:    "write-failed",
+				Code:    "CPL004",
 				Text:    "Couldn't write .clothingplugins file.",
 				Details: Ptr(err.Error()),
 			})
 		} else {
 			notes = append(notes, Note{
 				Variant: "success",
+				Rule:    "file-saved",
+				Code:    "CPL005",
 				Text:    "File saved.",
 				Details: Ptr(string(pretty.Pretty(newJson))),
 			})
+			isModified = true
 		}
 	} else {
-		notes = append(notes, Note{Variant: "info", Text: "No <code>\"Custom/*\"</code> paths to namespace. All good."})
+		notes = append(notes, Note{Variant: "info", Rule: "paths-ok", Code: "CPL006"}.WithSegments(Plain("No "), Code(`"Custom/*"`), Plain(" paths to namespace. All good.")))
 	}
 
-	return &Message{Icon: Ptr("file"), Title: path, Notes: notes}
+	return &Message{Icon: Ptr("file"), Title: path, Notes: notes, Modified: isModified}
 }
 
-func FixVap(path string) *Message {
-	_, packageName, _, isPrepped := getPreppedPackageName(path)
+func FixVap(ctx context.Context, path string, namespaceVersion string) *Message {
+	_, packageName, packageVersion, isPrepped := getPreppedPackageName(path)
 	if !isPrepped {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "info", Text: "Not in release prep mode, no changes necessary.",
+			Variant: "info", Rule: "not-prepped", Code: "VAP001", Text: "Not in release prep mode, no changes necessary.",
 		}}}
 	}
-	packageNamespace := packageName + ".latest"
+	packageNamespace := getPackageNamespace(packageName, packageVersion, namespaceVersion)
+
+	defer fileLocks.Lock(path)()
 
 	json, err := os.ReadFile(path)
 	if err != nil {
 		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "danger", Text: "Couldn't read file.", Details: Ptr(err.Error()),
+			Variant: "danger", Rule: "read-failed", Code: "VAP002", Text: "Couldn't read file.", Details: Ptr(err.Error()),
 		}}}
 	}
 
+	isModified := false
 	notes := []Note{}
 	storables := gjson.GetBytes(json, "storables")
 
 	if !storables.Exists() {
-		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-			Variant: "danger", Text: "Invalid .vap file. Missing \"storables\" property.",
-		}}}
+		return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{Note{
+			Variant: "danger", Rule: "invalid-structure", Code: "VAP003", Path: "storables",
+		}.WithSegments(Plain("Invalid .vap file. Missing "), Code(`"storables"`), Plain(" property."))}}
 	}
 
 	managerSuffix := "Stopper.ClothingPluginManager"
 	newJson := json
+	// Paths of storables whose paths were namespaced
+	var namespaced []string
 
 	for i, item := range storables.Array() {
 		id := item.Get("id")
@@ -281,48 +344,69 @@ func FixVap(path string) *Message {
 			newJson, err = sjson.SetRawBytes(newJson, prop, namespacedJson)
 			if err != nil {
 				return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
-					Variant: "danger", Text: "Couldn't update storable.", Details: Ptr(err.Error()),
+					Variant: "danger", Rule: "storable-update-failed", Code: "VAP004", Path: prop, Text: "Couldn't update storable.", Details: Ptr(err.Error()),
 				}}}
 			}
+			if !bytes.Equal(namespacedJson, []byte(item.Raw)) {
+				namespaced = append(namespaced, prop)
+			}
 		}
 	}
 
 	if !bytes.Equal(newJson, json) {
+		namespacedPath := "storables"
+		if len(namespaced) == 1 {
+			namespacedPath = namespaced[0]
+		}
 		notes = append(notes, Note{
 			Variant: "success",
+			Rule:    "paths-namespaced",
+			Code:    "VAP005",
+			Path:    namespacedPath,
 			Text:    "Namespaced custom paths to package name.",
 			Details: Ptr(fmt.Sprintf("Local \"Custom/*\" and \"SELF:/\" paths in plugin's storables have been namespaced to \"%s:/\".", packageNamespace)),
 		})
 
+		if err := ctx.Err(); err != nil {
+			return cancelledMessage(path, "VAP009", err)
+		}
+
 		jsonPretty := pretty.PrettyOptions(newJson, &pretty.Options{Indent: "\t"})
 		err = os.WriteFile(path, jsonPretty, 0644)
 		if err != nil {
 			notes = append(notes, Note{
 				Variant: "danger",
+				Rule:    "write-failed",
+				Code:    "VAP006",
 				Text:    "Couldn't write .vap file.",
 				Details: Ptr(err.Error()),
 			})
 		} else {
 			notes = append(notes, Note{
 				Variant: "success",
+				Rule:    "file-saved",
+				Code:    "VAP007",
 				Text:    "File saved.",
 				Details: Ptr(string(pretty.Pretty(newJson))),
 			})
+			isModified = true
 		}
 	} else {
-		notes = append(notes, Note{Variant: "info", Text: "No <code>\"Custom/*\"</code> paths to namespace. All good."})
+		notes = append(notes, Note{Variant: "info", Rule: "paths-ok", Code: "VAP008"}.WithSegments(Plain("No "), Code(`"Custom/*"`), Plain(" paths to namespace. All good.")))
 	}
 
-	return &Message{Icon: Ptr("file"), Title: path, Notes: notes}
+	return &Message{Icon: Ptr("file"), Title: path, Notes: notes, Modified: isModified}
 }
 
 var ItemGenderExp = regexp.MustCompile(`(?i)^.*/custom/(hair|clothing)/(female|male)/[^/]+/.*\.vam`)
 
-func FixItemGender(vamFilePath string) *Message {
+func FixItemGender(ctx context.Context, vamFilePath string) *Message {
 	matches := ItemGenderExp.FindStringSubmatch(vamFilePath)
 	if matches == nil || len(matches) < 3 {
 		return &Message{Icon: Ptr("file"), Title: vamFilePath, Notes: []Note{{
 			Variant: "danger",
+			Rule:    "invalid-path",
+			Code:    "GEN001",
 			Text:    "Invalid hair/clothing item path.",
 			Details: Ptr(fmt.Sprintf("Has to match:\nCustom/(Hair|Clothing)/(Female|Male)/{author}/{item}/{item}.vam\n\nReceived:\n%s", vamFilePath)),
 		}}}
@@ -334,10 +418,12 @@ func FixItemGender(vamFilePath string) *Message {
 	gender := strings.ToUpper(matches[2][0:1]) + strings.ToLower(matches[2][1:])
 	itemTypeByDirectory := kind + gender
 
+	defer fileLocks.Lock(vamFilePath)()
+
 	json, err := os.ReadFile(vamFilePath)
 	if err != nil {
 		return &Message{Icon: Ptr("file"), Title: vamFilePath, Notes: []Note{{
-			Variant: "danger", Text: "Couldn't read file.", Details: Ptr(err.Error()),
+			Variant: "danger", Rule: "read-failed", Code: "GEN002", Text: "Couldn't read file.", Details: Ptr(err.Error()),
 		}}}
 	}
 
@@ -346,6 +432,9 @@ func FixItemGender(vamFilePath string) *Message {
 	if itemTypeByDirectory == currentItemType {
 		return &Message{Icon: Ptr("file"), Title: vamFilePath, Notes: []Note{{
 			Variant: "info",
+			Rule:    "item-type-ok",
+			Code:    "GEN003",
+			Path:    "itemType",
 			Text:    "Item type is already correct.",
 			Details: Ptr(currentItemType),
 		}}}
@@ -354,32 +443,49 @@ func FixItemGender(vamFilePath string) *Message {
 	newJson, err := sjson.SetBytes(json, "itemType", itemTypeByDirectory)
 	if err != nil {
 		return &Message{Icon: Ptr("file"), Title: vamFilePath, Notes: []Note{{
-			Variant: "danger", Text: "Couldn't set itemType.", Details: Ptr(err.Error()),
+			Variant: "danger", Rule: "item-type-set-failed", Code: "GEN004", Path: "itemType", Text: "Couldn't set itemType.", Details: Ptr(err.Error()),
 		}}}
 	}
 
 	notes = append(notes, Note{
 		Variant: "success",
-		Text:    fmt.Sprintf("Item type changed to <b>%s</b>.", itemTypeByDirectory),
-	})
+		Rule:    "item-type-changed",
+		Code:    "GEN005",
+		Path:    "itemType",
+	}.WithSegments(Plain("Item type changed to "), Emphasis(itemTypeByDirectory), Plain(".")))
+
+	if err := ctx.Err(); err != nil {
+		return cancelledMessage(vamFilePath, "GEN008", err)
+	}
 
 	jsonPretty := pretty.PrettyOptions(newJson, &pretty.Options{Indent: "\t"})
 	err = os.WriteFile(vamFilePath, jsonPretty, 0644)
 	if err != nil {
 		notes = append(notes, Note{
 			Variant: "danger",
+			Rule:    "write-failed",
+			Code:    "GEN006",
 			Text:    "Couldn't write .vam file.",
 			Details: Ptr(err.Error()),
 		})
 	} else {
 		notes = append(notes, Note{
 			Variant: "success",
+			Rule:    "file-saved",
+			Code:    "GEN007",
 			Text:    "File saved.",
 			Details: Ptr(string(pretty.Pretty(newJson))),
 		})
 	}
 
-	return &Message{Icon: Ptr("file"), Title: vamFilePath, Notes: notes}
+	return &Message{Icon: Ptr("file"), Title: vamFilePath, Notes: notes, Modified: err == nil}
+}
+
+// Message for a file that was left untouched because the operation got cancelled.
+func cancelledMessage(path string, code string, err error) *Message {
+	return &Message{Icon: Ptr("file"), Title: path, Notes: []Note{{
+		Variant: "warning", Rule: "cancelled", Code: code, Text: "Operation cancelled, file left unchanged.", Details: Ptr(err.Error()),
+	}}}
 }
 
 var clothingBaseDirExp = regexp.MustCompile(`(?i)(^.*/custom/clothing/(?:female|male)/[^/]+/[^/]+)`)
@@ -388,6 +494,28 @@ var clothingBaseDirExp = regexp.MustCompile(`(?i)(^.*/custom/clothing/(?:female|
 // Requires `path` normalized to forward slashes.
 // Also requires clothing to use standard `Custom/Clothing/{gender}/{author}/{clothing_name}` folder structure.
 func getUID(path string) (string, error) {
+	vamFilePath, err := findVamFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	unlock := fileLocks.Lock(vamFilePath)
+	data, err := ReadJSON[map[string]interface{}](vamFilePath)
+	unlock()
+	if err != nil {
+		return "", fmt.Errorf("getUID: couldn't read .vam file \"%s\", error: %v", path, err)
+	}
+
+	uid, ok := data["uid"].(string)
+	if !ok || len(uid) < 3 {
+		return "", fmt.Errorf("getUID: couldn't find valid uid property inside .vam file \"%s\"", path)
+	}
+
+	return uid, nil
+}
+
+// Path of the `.vam` file of the clothing item the path belongs to, with the same requirements as `getUID`.
+func findVamFile(path string) (string, error) {
 	matches := clothingBaseDirExp.FindStringSubmatch(path)
 	if matches == nil || len(matches) < 2 {
 		return "", fmt.Errorf("getUID: invalid path \"%s\". clothing has to be inside VaM's Custom/Clothing/{gender}/{author}/{name} directory", path)
@@ -413,18 +541,7 @@ func getUID(path string) (string, error) {
 		return "", fmt.Errorf("getUID: couldn't find accompanying .vam file for \"%s\"", path)
 	}
 
-	vamFilePath := dirPath + "/" + vamFile.Name()
-	data, err := ReadJSON[map[string]interface{}](vamFilePath)
-	if err != nil {
-		return "", fmt.Errorf("getUID: couldn't read .vam file \"%s\", error: %v", path, err)
-	}
-
-	uid, ok := data["uid"].(string)
-	if !ok || len(uid) < 3 {
-		return "", fmt.Errorf("getUID: couldn't find valid uid property inside .vam file \"%s\"", path)
-	}
-
-	return uid, nil
+	return dirPath + "/" + vamFile.Name(), nil
 }
 
 var preppedPackageExp = regexp.MustCompile(`(?i)/AddonPackagesBuilder/([^/]+)\.var/.*`)
@@ -445,6 +562,25 @@ func getPreppedPackageName(path string) (full string, authorAndName string, vers
 	return matches[1], strings.Join(parts[:2], "."), parts[2], true
 }
 
+// Version policies for namespacing paths to the package itself.
+const (
+	NamespaceLatest = "latest"
+	NamespaceExact  = "exact"
+	NamespaceMin    = "min"
+)
+
+// Builds package reference namespace, e.g. `Author.Package.latest`, `Author.Package.3`, or `Author.Package.min3`.
+func getPackageNamespace(authorAndName string, version string, policy string) string {
+	switch policy {
+	case NamespaceExact:
+		return authorAndName + "." + version
+	case NamespaceMin:
+		return authorAndName + ".min" + version
+	default:
+		return authorAndName + ".latest"
+	}
+}
+
 var localPathExp = regexp.MustCompile(`(?i)"/?Custom/`)
 
 // Converts all relative paths (`Custom/*`, `SELF:/*`) in a json byte slice to have passed package name as root.
@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// Returns the path of the note with the code, failing when there's none.
+func notePath(t *testing.T, message *Message, code string) string {
+	t.Helper()
+	for _, note := range message.Notes {
+		if note.Code == code {
+			return note.Path
+		}
+	}
+	t.Fatalf("no %s note in %+v", code, message.Notes)
+	return ""
+}
+
+func TestFixerNotePaths(t *testing.T) {
+	item := "AddonPackagesBuilder/Me.Pkg.1.var/Custom/Clothing/Female/A/I/"
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		item + "I.vam": `{"itemType":"ClothingFemale","uid":"A:I"}`,
+		item + "I.vaj": `{"components":[{"type":"Other"}],"storables":[]}`,
+		item + "I.vap": `{"storables":[{"id":"x"},{"id":"A:I_Stopper.ClothingPluginManager","plugins":{},"path":"Custom/a.png"}]}`,
+	})
+	vaj := path.Join(root, item+"I.vaj")
+	vap := path.Join(root, item+"I.vap")
+
+	read := func(filePath string) []byte {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	added := notePath(t, FixVaj(context.Background(), vaj, false), "VAJ008")
+	if got := gjson.GetBytes(read(vaj), added+".type").String(); got != "MVRPluginManager" {
+		t.Errorf("VAJ008 path %q points to type %q", added, got)
+	}
+	present := notePath(t, FixVaj(context.Background(), vaj, true), "VAJ005")
+	if present != added {
+		t.Errorf("VAJ005 path %q, want %q", present, added)
+	}
+
+	namespaced := notePath(t, FixVap(context.Background(), vap, ""), "VAP005")
+	if got := gjson.GetBytes(read(vap), namespaced+".path").String(); got != "Me.Pkg.latest:/Custom/a.png" {
+		t.Errorf("VAP005 path %q points to path %q", namespaced, got)
+	}
+}
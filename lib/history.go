@@ -0,0 +1,227 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrHistoryRunNotFound = errors.New("run isn't in history")
+
+// Finished run as stored in history.
+type HistoryRun struct {
+	ID       string     `json:"id"`
+	Summary  *Summary   `json:"summary"`
+	Messages []*Message `json:"messages"`
+}
+
+// Stored run without its messages.
+type HistoryEntry struct {
+	ID      string   `json:"id"`
+	Summary *Summary `json:"summary"`
+	// Size of the stored run in bytes.
+	Size int64 `json:"size"`
+}
+
+// Messages of a stored run about files matching a search.
+type HistoryMatch struct {
+	Entry    *HistoryEntry `json:"entry"`
+	Messages []*Message    `json:"messages"`
+}
+
+// IDs are derived from the time runs started, so they sort chronologically.
+const historyIDLayout = "20060102T150405.000000000"
+
+var historyIDExp = regexp.MustCompile(`^\d{8}T\d{6}\.\d{9}$`)
+
+// Stores finished runs as `<id>.json` files in a directory.
+type HistoryStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+func NewHistoryStore(dir string) *HistoryStore {
+	return &HistoryStore{Dir: dir}
+}
+
+func (h *HistoryStore) path(id string) string {
+	return filepath.Join(h.Dir, id+".json")
+}
+
+// Stores messages of a finished run. Returns its history entry.
+func (h *HistoryStore) Add(summary *Summary, messages []*Message) (*HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return nil, err
+	}
+	id := summary.Started.UTC().Format(historyIDLayout)
+	// Runs started at the same time, e.g. in parallel, get the next free nanosecond
+	for started := summary.Started; ; {
+		if _, err := os.Stat(h.path(id)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		started = started.Add(time.Nanosecond)
+		id = started.UTC().Format(historyIDLayout)
+	}
+
+	data, err := json.Marshal(&HistoryRun{ID: id, Summary: summary, Messages: messages})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(h.path(id), data, 0644); err != nil {
+		return nil, err
+	}
+	return &HistoryEntry{ID: id, Summary: summary, Size: int64(len(data))}, nil
+}
+
+// All stored runs, newest first. Runs that can't be read are left out.
+func (h *HistoryStore) List() ([]*HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.list()
+}
+
+func (h *HistoryStore) list() ([]*HistoryEntry, error) {
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*HistoryEntry, 0, len(ids))
+	for _, id := range slices.Backward(ids) {
+		entry, err := h.entry(id)
+		if err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Sorted IDs of all stored runs, oldest first.
+func (h *HistoryStore) ids() ([]string, error) {
+	files, err := os.ReadDir(h.Dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	ids := []string{}
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".json")
+		if ok && !file.IsDir() && historyIDExp.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (h *HistoryStore) entry(id string) (*HistoryEntry, error) {
+	data, err := os.ReadFile(h.path(id))
+	if err != nil {
+		return nil, err
+	}
+	entry := &HistoryEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	entry.ID = id
+	entry.Size = int64(len(data))
+	return entry, nil
+}
+
+func (h *HistoryStore) Load(id string) (*HistoryRun, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.load(id)
+}
+
+func (h *HistoryStore) load(id string) (*HistoryRun, error) {
+	if !historyIDExp.MatchString(id) {
+		return nil, fmt.Errorf("invalid history run ID \"%s\"", id)
+	}
+	data, err := os.ReadFile(h.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrHistoryRunNotFound, id)
+	} else if err != nil {
+		return nil, err
+	}
+	run := &HistoryRun{}
+	if err := json.Unmarshal(data, run); err != nil {
+		return nil, fmt.Errorf("run \"%s\" is corrupt: %w", id, err)
+	}
+	run.ID = id
+	return run, nil
+}
+
+// Finds messages about files whose path contains the query, case insensitive. Runs are searched
+// newest first, and only those with a match are returned.
+func (h *HistoryStore) Search(query string) ([]*HistoryMatch, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(query)
+	matches := []*HistoryMatch{}
+	for _, id := range slices.Backward(ids) {
+		run, err := h.load(id)
+		if err != nil {
+			continue
+		}
+		var messages []*Message
+		for _, message := range run.Messages {
+			if isFileMessage(message) && strings.Contains(strings.ToLower(message.Title), query) {
+				messages = append(messages, message)
+			}
+		}
+		if len(messages) > 0 {
+			matches = append(matches, &HistoryMatch{Entry: &HistoryEntry{ID: id, Summary: run.Summary}, Messages: messages})
+		}
+	}
+	return matches, nil
+}
+
+// Deletes runs that started more than maxAge ago, then the oldest runs until all of them fit
+// into maxSize bytes. Zero disables the respective limit. Returns the number of deleted runs.
+func (h *HistoryStore) Prune(maxAge time.Duration, maxSize int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries, err := h.list()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+
+	deleted := 0
+	// Entries are newest first, so pruning goes from the end
+	for _, entry := range slices.Backward(entries) {
+		tooOld := maxAge > 0 && entry.Summary != nil && time.Since(entry.Summary.Started) > maxAge
+		tooBig := maxSize > 0 && size > maxSize
+		if !tooOld && !tooBig {
+			break
+		}
+		if err := os.Remove(h.path(entry.ID)); err != nil {
+			return deleted, err
+		}
+		size -= entry.Size
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,200 @@
+package lib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Name of gitignore syntax files listing paths the walk should skip.
+const IgnoreFileName = ".cpuignore"
+
+// Pattern that can't be turned into a regular expression, e.g. with a reversed range `[z-a]`.
+var ErrInvalidIgnorePattern = errors.New("invalid pattern")
+
+// Single gitignore syntax pattern.
+type IgnorePattern struct {
+	// Directory the pattern is relative to, normalized to forward slashes.
+	Base    string
+	Negate  bool
+	DirOnly bool
+	exp     *regexp.Regexp
+}
+
+// Parses a gitignore syntax pattern. Returns nil for blank lines and comments.
+func ParseIgnorePattern(base string, line string) (*IgnorePattern, error) {
+	line = strings.TrimRight(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	pattern := &IgnorePattern{Base: base}
+	if strings.HasPrefix(line, "!") {
+		pattern.Negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	// Patterns with a slash at the beginning or in the middle are relative to the base,
+	// otherwise they match at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	exp, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w \"%s\": %w", ErrInvalidIgnorePattern, line, err)
+	}
+	pattern.exp = exp
+	return pattern, nil
+}
+
+// Converts gitignore glob syntax to a regular expression.
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "/**":
+			expr.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString("\\[")
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// Whether the pattern matches a path (normalized to forward slashes) inside its base.
+func (p *IgnorePattern) Match(filePath string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	rel, ok := relativeTo(p.Base, filePath)
+	if !ok {
+		return false
+	}
+	return p.exp.MatchString(rel)
+}
+
+func relativeTo(base string, filePath string) (string, bool) {
+	if base == "" {
+		return filePath, true
+	}
+	if !strings.HasPrefix(strings.ToLower(filePath), strings.ToLower(base)+"/") {
+		return "", false
+	}
+	return filePath[len(base)+1:], true
+}
+
+// Ordered list of patterns, later patterns take precedence.
+type IgnoreList []*IgnorePattern
+
+// Reads patterns from a gitignore syntax file. Missing file results in an empty list.
+// Invalid patterns are skipped and the first such error is returned along with the valid ones.
+func ReadIgnoreFile(filePath string) (IgnoreList, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	base := path.Dir(filePath)
+	var list IgnoreList
+	var firstErr error
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		pattern, err := ParseIgnorePattern(base, scanner.Text())
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if pattern != nil {
+			list = append(list, pattern)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return list, err
+	}
+	return list, firstErr
+}
+
+// Parses patterns relative to base. Invalid patterns are skipped, use `ValidateIgnorePatterns` to catch them.
+func NewIgnoreList(base string, lines []string) IgnoreList {
+	var list IgnoreList
+	for _, line := range lines {
+		if pattern, _ := ParseIgnorePattern(base, line); pattern != nil {
+			list = append(list, pattern)
+		}
+	}
+	return list
+}
+
+func ValidateIgnorePatterns(lines []string) error {
+	for _, line := range lines {
+		if _, err := ParseIgnorePattern("", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Whether the path is ignored. The last matching pattern decides, so negated patterns can re-include paths.
+func (l IgnoreList) Ignored(filePath string, isDir bool) bool {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].Match(filePath, isDir) {
+			return !l[i].Negate
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIgnoreListIgnored(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		// Unanchored patterns match at any depth
+		{[]string{"*.psd"}, "base/a.psd", false, true},
+		{[]string{"*.psd"}, "base/x/y/a.PSD", false, true},
+		{[]string{"*.psd"}, "base/a.psd.txt", false, false},
+		{[]string{"*.psd"}, "other/a.psd", false, false},
+		// Slash at the beginning or in the middle anchors to the base
+		{[]string{"/a.txt"}, "base/a.txt", false, true},
+		{[]string{"/a.txt"}, "base/x/a.txt", false, false},
+		{[]string{"x/a.txt"}, "base/x/a.txt", false, true},
+		{[]string{"x/a.txt"}, "base/y/x/a.txt", false, false},
+		// Single star and question mark don't cross directories
+		{[]string{"x/*.txt"}, "base/x/y/a.txt", false, false},
+		{[]string{"x/?.txt"}, "base/x/a.txt", false, true},
+		{[]string{"x/?.txt"}, "base/x/ab.txt", false, false},
+		// Double star
+		{[]string{"**/WIP"}, "base/WIP", true, true},
+		{[]string{"**/WIP"}, "base/x/y/WIP", true, true},
+		{[]string{"x/**"}, "base/x/y/a.txt", false, true},
+		{[]string{"x/**"}, "base/x", true, false},
+		{[]string{"x/**/a.txt"}, "base/x/a.txt", false, true},
+		{[]string{"x/**/a.txt"}, "base/x/y/z/a.txt", false, true},
+		// Dir only
+		{[]string{"WIP/"}, "base/WIP", true, true},
+		{[]string{"WIP/"}, "base/WIP", false, false},
+		// Negation, last matching pattern decides
+		{[]string{"*.txt", "!keep.txt"}, "base/keep.txt", false, false},
+		{[]string{"*.txt", "!keep.txt"}, "base/drop.txt", false, true},
+		{[]string{"!keep.txt", "*.txt"}, "base/keep.txt", false, true},
+		// Character classes
+		{[]string{"[ab].txt"}, "base/b.txt", false, true},
+		{[]string{"[!ab].txt"}, "base/b.txt", false, false},
+		{[]string{"[!ab].txt"}, "base/c.txt", false, true},
+		// Escapes, comments, and blank lines
+		{[]string{`\#a`}, "base/#a", false, true},
+		{[]string{`\!a`}, "base/!a", false, true},
+		{[]string{"#a", ""}, "base/#a", false, false},
+		{[]string{"a\\*"}, "base/ab", false, false},
+		{[]string{"a\\*"}, "base/a*", false, true},
+	}
+	for _, test := range tests {
+		list := NewIgnoreList("base", test.patterns)
+		if got := list.Ignored(test.path, test.isDir); got != test.want {
+			t.Errorf("%q ignores %q (dir %v) = %v, want %v", test.patterns, test.path, test.isDir, got, test.want)
+		}
+	}
+}
+
+func TestParseIgnorePatternInvalid(t *testing.T) {
+	pattern, err := ParseIgnorePattern("base", "[z-a]")
+	if pattern != nil || !errors.Is(err, ErrInvalidIgnorePattern) {
+		t.Errorf("got %v, %v, want ErrInvalidIgnorePattern", pattern, err)
+	}
+	if err := ValidateIgnorePatterns([]string{"*.txt", "[z-a]"}); !errors.Is(err, ErrInvalidIgnorePattern) {
+		t.Errorf("ValidateIgnorePatterns returned %v, want ErrInvalidIgnorePattern", err)
+	}
+}
+
+func TestReadIgnoreFileSkipsInvalidPatterns(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		IgnoreFileName: "*.psd\n[z-a]\n!keep.psd\n",
+	})
+	list, err := ReadIgnoreFile(root + "/" + IgnoreFileName)
+	if !errors.Is(err, ErrInvalidIgnorePattern) {
+		t.Errorf("error %v, want ErrInvalidIgnorePattern", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("read %d patterns, want 2 valid ones", len(list))
+	}
+	if !list.Ignored(root+"/a.psd", false) || list.Ignored(root+"/keep.psd", false) {
+		t.Error("valid patterns around the invalid one don't apply")
+	}
+}
@@ -0,0 +1,214 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Job step that packs package directories into .var files instead of running an operation.
+const StepBuild = "build"
+
+// Ordered steps run one after another, e.g. a whole release procedure. Stops at the first step that
+// produces errors.
+type Job struct {
+	// Displayed in messages, defaults to the job file name.
+	Name  string    `json:"name"`
+	Steps []JobStep `json:"steps"`
+	// File the combined report of all steps is written to, format by extension.
+	Report string `json:"report,omitempty"`
+}
+
+type JobStep struct {
+	// Operation to run (init, fix, gender, validate), or build.
+	Run   string   `json:"run"`
+	Paths []string `json:"paths"`
+	// Names of fixers to run, all fixers run when empty.
+	Fixers []string `json:"fixers,omitempty"`
+	// Process all files, even unchanged ones.
+	Force bool `json:"force,omitempty"`
+	// Archive build steps write, defaults to `AddonPackages/<package>.var` next to
+	// `AddonPackagesBuilder`. Requires a single path.
+	Output string `json:"output,omitempty"`
+}
+
+// Reads a job file. Relative paths in it are resolved against the file's directory.
+func LoadJob(filePath string) (*Job, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	job := &Job{}
+	if err := decoder.Decode(job); err != nil {
+		return nil, fmt.Errorf("invalid job file \"%s\": %w", filePath, err)
+	}
+	if job.Name == "" {
+		job.Name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+
+	dir := filepath.Dir(filePath)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	job.Report = resolve(job.Report)
+	for i := range job.Steps {
+		step := &job.Steps[i]
+		for j, p := range step.Paths {
+			step.Paths[j] = resolve(p)
+		}
+		step.Output = resolve(step.Output)
+	}
+
+	if err := job.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid job file \"%s\": %w", filePath, err)
+	}
+	return job, nil
+}
+
+func (j *Job) Validate() error {
+	if len(j.Steps) == 0 {
+		return fmt.Errorf("job has no steps")
+	}
+	if j.Report != "" {
+		if _, err := ReportFormatFromPath(j.Report); err != nil {
+			return err
+		}
+	}
+	for i, step := range j.Steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *JobStep) validate() error {
+	if len(s.Paths) == 0 {
+		return fmt.Errorf("no paths")
+	}
+	if s.Run == StepBuild {
+		if s.Output != "" && len(s.Paths) > 1 {
+			return fmt.Errorf("output requires a single path")
+		}
+		if len(s.Fixers) > 0 || s.Force {
+			return fmt.Errorf("build doesn't take fixers or force")
+		}
+		return nil
+	}
+	if s.Output != "" {
+		return fmt.Errorf("only build steps take output")
+	}
+	if !slices.Contains(Operations, Operation(s.Run)) {
+		return fmt.Errorf("unknown step \"%s\", use %s, or %s", s.Run, joinOperations(), StepBuild)
+	}
+	return (&RunOptions{Fixers: s.Fixers}).Validate()
+}
+
+func joinOperations() string {
+	names := make([]string, len(Operations))
+	for i, op := range Operations {
+		names[i] = string(op)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Runs job steps in order until one of them produces errors or the context is cancelled. Options
+// apply to all steps, fixers set by a step replace them for that step. Ends by reporting a summary
+// of all steps combined, which is also returned, and writing the job's report when it names one.
+func RunJob(ctx context.Context, reporter Reporter, job *Job, options RunOptions) *Summary {
+	memory := NewMemoryReporter()
+	reporter = MultiReporter{reporter, memory}
+	config := options.Config
+	if config == nil {
+		config = NewAppConfig()
+	}
+
+	var roots []string
+	for _, step := range job.Steps {
+		for _, p := range step.Paths {
+			if !slices.Contains(roots, p) {
+				roots = append(roots, p)
+			}
+		}
+	}
+	summary := NewSummary("job", roots)
+
+	completed := 0
+	var failed *JobStep
+	for i := range job.Steps {
+		if ctx.Err() != nil {
+			break
+		}
+		step := &job.Steps[i]
+		var stepSummary *Summary
+		if step.Run == StepBuild {
+			stepSummary = NewSummary(StepBuild, step.Paths)
+			for _, root := range step.Paths {
+				output := step.Output
+				if output == "" {
+					output = DefaultPackageOutput(root)
+				}
+				stepSummary.Merge(BuildPackage(ctx, reporter, root, output, config))
+			}
+		} else {
+			stepOptions := options
+			stepOptions.Config = config
+			stepOptions.Force = options.Force || step.Force
+			if len(step.Fixers) > 0 {
+				stepOptions.Fixers = step.Fixers
+			}
+			stepSummary = Run(ctx, reporter, Operation(step.Run), step.Paths, stepOptions)
+		}
+		summary.Merge(stepSummary)
+		if stepSummary.Variants[Error] > 0 {
+			failed = step
+			break
+		}
+		if ctx.Err() == nil {
+			completed++
+		}
+	}
+
+	summary.Finish(summary.Scanned, summary.Processed)
+	message := summary.Message()
+	message.Title = fmt.Sprintf("Summary (job %s)", job.Name)
+	note := Note{Variant: Success, Rule: "job-done", Code: "CPU014", Text: fmt.Sprintf("Completed all %d steps.", len(job.Steps))}
+	switch {
+	case failed != nil:
+		note = Note{Variant: Error, Rule: "job-failed", Code: "CPU013", Text: fmt.Sprintf(
+			"Stopped at step %d (%s) because it produced errors, %d of %d steps completed.",
+			completed+1, failed.Run, completed, len(job.Steps),
+		)}
+	case completed < len(job.Steps):
+		note = Note{Variant: Warning, Rule: "job-cancelled", Code: "CPU015", Text: fmt.Sprintf(
+			"Job was cancelled, %d of %d steps completed.", completed, len(job.Steps),
+		)}
+	}
+	message.Notes = append(message.Notes, note)
+	reporter.Message(message)
+
+	if job.Report != "" {
+		if err := NewReport(memory.Messages()).WriteFile(job.Report); err != nil {
+			message := &Message{Icon: Ptr("file"), Title: job.Report, Notes: []Note{{
+				Variant: Error,
+				Rule:    "report-failed",
+				Code:    "CPU016",
+				Text:    "Couldn't write job report.",
+				Details: Ptr(err.Error()),
+			}}}
+			summary.Add(message)
+			reporter.Message(message)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestLoadJobResolvesPaths(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"jobs/release.json": `{
+			"steps": [
+				{"run": "fix", "paths": ["pkg", "/abs"]},
+				{"run": "build", "paths": ["pkg"], "output": "out/pkg.var"}
+			],
+			"report": "report.md"
+		}`,
+	})
+
+	job, err := LoadJob(path.Join(root, "jobs/release.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Name != "release" {
+		t.Errorf("name %q, want file name", job.Name)
+	}
+	dir := path.Join(root, "jobs")
+	if job.Report != path.Join(dir, "report.md") {
+		t.Errorf("report %q isn't resolved against the job file", job.Report)
+	}
+	if job.Steps[0].Paths[0] != path.Join(dir, "pkg") || job.Steps[0].Paths[1] != "/abs" {
+		t.Errorf("paths %v aren't resolved against the job file", job.Steps[0].Paths)
+	}
+	if job.Steps[1].Output != path.Join(dir, "out/pkg.var") {
+		t.Errorf("output %q isn't resolved against the job file", job.Steps[1].Output)
+	}
+}
+
+func TestLoadJobInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":    `{"steps": [{"run": "fix", "paths": ["."]}], "extra": 1}`,
+		"no steps":         `{"steps": []}`,
+		"no paths":         `{"steps": [{"run": "fix"}]}`,
+		"unknown step":     `{"steps": [{"run": "deploy", "paths": ["."]}]}`,
+		"unknown fixer":    `{"steps": [{"run": "fix", "paths": ["."], "fixers": ["nope"]}]}`,
+		"output on fix":    `{"steps": [{"run": "fix", "paths": ["."], "output": "a.var"}]}`,
+		"build fixers":     `{"steps": [{"run": "build", "paths": ["."], "fixers": ["vaj"]}]}`,
+		"build two output": `{"steps": [{"run": "build", "paths": ["a", "b"], "output": "a.var"}]}`,
+		"report format":    `{"steps": [{"run": "fix", "paths": ["."]}], "report": "report.pdf"}`,
+	}
+	for name, data := range tests {
+		root := writeFiles(t, t.TempDir(), map[string]string{"job.json": data})
+		if _, err := LoadJob(path.Join(root, "job.json")); err == nil {
+			t.Errorf("%s: job loaded", name)
+		}
+	}
+}
+
+func TestRunJobStopsAtFailedStep(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Clothing/Female/A/I/I.vam": maleItem,
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Item.vaj":                  `{"a": "SELF:/Custom/missing.png"}`,
+	})
+	pkg := path.Join(root, "AddonPackagesBuilder/Me.Pkg.1.var")
+	job := &Job{Name: "release", Report: path.Join(root, "report.md"), Steps: []JobStep{
+		{Run: string(OpGender), Paths: []string{pkg}},
+		{Run: string(OpValidate), Paths: []string{pkg}},
+		{Run: StepBuild, Paths: []string{pkg}},
+	}}
+
+	memory := NewMemoryReporter()
+	summary := RunJob(context.Background(), memory, job, RunOptions{})
+
+	if !hasCode(memory.Messages(), "GEN005") || !hasCode(memory.Messages(), "REF004") {
+		t.Error("gender and validate steps didn't run")
+	}
+	if !hasCode(memory.Messages(), "CPU013") || hasCode(memory.Messages(), "VAR004") {
+		t.Error("job didn't stop at the validate step")
+	}
+	if summary.Modified != 1 || summary.Variants[Error] == 0 {
+		t.Errorf("summary modified %d with %d errors, want 1 and some", summary.Modified, summary.Variants[Error])
+	}
+	if _, err := os.Stat(DefaultPackageOutput(pkg)); err == nil {
+		t.Error("package was built after a failed step")
+	}
+	report, err := os.ReadFile(job.Report)
+	if err != nil {
+		t.Fatalf("job report wasn't written: %v", err)
+	}
+	if !strings.Contains(string(report), "Summary (validate)") || !strings.Contains(string(report), "missing.png") {
+		t.Error("job report doesn't include messages of its steps")
+	}
+}
+
+func TestRunJobReportsUnwritableReport(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"Custom/Clothing/Female/A/I/I.vam": maleItem,
+	})
+	job := &Job{Name: "job", Report: path.Join(root, "missing/dir/report.md"), Steps: []JobStep{
+		{Run: string(OpGender), Paths: []string{root}},
+	}}
+
+	memory := NewMemoryReporter()
+	summary := RunJob(context.Background(), memory, job, RunOptions{})
+
+	if !hasCode(memory.Messages(), "CPU016") || summary.Variants[Error] != 1 {
+		t.Errorf("unwritable report wasn't reported as an error, %d errors", summary.Variants[Error])
+	}
+}
@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnit XML as understood by most CI dashboards.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Writes the report as JUnit XML. Each fixer is a test suite and each of its messages a test case,
+// which fails when it has error or warning notes. The failure lists all of them, as most dashboards
+// show only one failure per case. Other notes are listed in the case's output.
+func (r *Report) writeJUnit(w io.Writer) error {
+	root := junitTestSuites{Name: "Clothing Plugins Util"}
+	suites := map[string]int{}
+
+	var seconds float64
+	for _, message := range r.Messages {
+		if message.Summary != nil {
+			seconds += message.Summary.Duration().Seconds()
+			continue
+		}
+
+		suiteName := message.Fixer
+		if suiteName == "" {
+			suiteName = "general"
+		}
+		index, ok := suites[suiteName]
+		if !ok {
+			index = len(root.Suites)
+			suites[suiteName] = index
+			root.Suites = append(root.Suites, junitTestSuite{Name: suiteName})
+		}
+		suite := &root.Suites[index]
+
+		testCase := junitTestCase{Name: message.Title, ClassName: suiteName}
+		var out, failed []string
+		for i := range message.Notes {
+			note := &message.Notes[i]
+			if note.Variant != Error && note.Variant != Warning {
+				out = append(out, fmt.Sprintf("[%s] %s", note.Variant, note.Text))
+				continue
+			}
+			text := fmt.Sprintf("[%s] %s: %s", note.Variant, noteRuleID(message, note), note.Text)
+			if note.Details != nil {
+				text += "\n" + *note.Details
+			}
+			if testCase.Failure == nil {
+				// Named after the first problem, the rest are in the text
+				testCase.Failure = &junitFailure{Message: note.Text, Type: noteRuleID(message, note)}
+			}
+			failed = append(failed, text)
+		}
+		testCase.SystemOut = strings.Join(out, "\n")
+
+		suite.Tests++
+		root.Tests++
+		if testCase.Failure != nil {
+			if len(failed) > 1 {
+				testCase.Failure.Message = fmt.Sprintf("%s (and %d more)", testCase.Failure.Message, len(failed)-1)
+			}
+			testCase.Failure.Text = strings.Join(failed, "\n\n")
+			suite.Failures++
+			root.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	root.Time = fmt.Sprintf("%.3f", seconds)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
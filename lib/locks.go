@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Per-path mutexes so concurrently running fixers never read/write the same file at once.
+type FileLocks struct {
+	mu    sync.Mutex
+	locks map[string]*fileLock
+}
+
+type fileLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func NewFileLocks() *FileLocks {
+	return &FileLocks{locks: map[string]*fileLock{}}
+}
+
+// Locks the path and returns a function that unlocks it.
+func (l *FileLocks) Lock(path string) (unlock func()) {
+	key := fileLockKey(path)
+
+	l.mu.Lock()
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &fileLock{}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Windows paths are case insensitive, so we lowercase everything. On other
+// systems this at worst serializes access to two distinct files.
+func fileLockKey(path string) string {
+	return strings.ToLower(filepath.ToSlash(filepath.Clean(path)))
+}
+
+var fileLocks = NewFileLocks()
@@ -0,0 +1,291 @@
+package lib
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run whose messages are kept in a MessageStore.
+type StoredRun struct {
+	ID        int       `json:"id"`
+	Operation string    `json:"operation"`
+	Roots     []string  `json:"roots"`
+	Started   time.Time `json:"started"`
+	// Number of messages the run produced so far.
+	Count int  `json:"count"`
+	Done  bool `json:"done"`
+}
+
+type StoredMessage struct {
+	// Position in the store, stable for the lifetime of the message.
+	Index int `json:"index"`
+	// Run that produced the message, 0 for messages produced outside of runs.
+	Run     int      `json:"run"`
+	Message *Message `json:"message"`
+}
+
+// Messages have to match all set conditions.
+type MessageFilter struct {
+	// Messages with at least one note of any of the variants.
+	Variants []Variant `json:"variants,omitempty"`
+	// Messages produced by any of the fixers.
+	Fixers []string `json:"fixers,omitempty"`
+	// Case insensitive substring of the message title, which is the path for file messages.
+	Path string `json:"path,omitempty"`
+	// Messages about files that were modified.
+	Modified bool `json:"modified,omitempty"`
+}
+
+func (f *MessageFilter) Match(message *Message) bool {
+	if len(f.Variants) > 0 && !slices.ContainsFunc(message.Notes, func(note Note) bool {
+		return slices.Contains(f.Variants, note.Variant)
+	}) {
+		return false
+	}
+	if len(f.Fixers) > 0 && !slices.Contains(f.Fixers, message.Fixer) {
+		return false
+	}
+	if f.Path != "" && !strings.Contains(strings.ToLower(message.Title), strings.ToLower(f.Path)) {
+		return false
+	}
+	return !f.Modified || message.Modified
+}
+
+type MessageQuery struct {
+	// Run to query, all runs and messages outside of them when 0.
+	Run    int           `json:"run"`
+	Filter MessageFilter `json:"filter"`
+	// Position in matching messages, newest first.
+	Offset int `json:"offset"`
+	// Maximum number of returned messages, all when 0.
+	Limit int `json:"limit"`
+}
+
+type MessagePage struct {
+	Messages []*StoredMessage `json:"messages"`
+	// Number of all messages matching the query.
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+}
+
+// Changes of a MessageStore since they were last taken.
+type MessageChanges struct {
+	// Copies of runs that started, got new messages, or finished.
+	Runs []*StoredRun `json:"runs"`
+	// IDs of runs that were evicted or cleared.
+	Removed []int `json:"removed"`
+	// Number of all stored messages.
+	Total int `json:"total"`
+}
+
+// Limits of a MessageStore, once exceeded the oldest finished runs are evicted.
+const (
+	maxStoredRuns     = 100
+	maxStoredMessages = 100_000
+)
+
+// Keeps messages of runs in memory, so they can be queried page by page instead of being
+// pushed to the frontend all at once.
+type MessageStore struct {
+	mu          sync.Mutex
+	runs        []*StoredRun
+	messages    []*StoredMessage
+	nextRun     int
+	next        int
+	maxRuns     int
+	maxMessages int
+	// Number of messages produced outside of runs.
+	outside int
+	changed map[int]bool
+	removed []int
+}
+
+func NewMessageStore() *MessageStore {
+	return &MessageStore{nextRun: 1, maxRuns: maxStoredRuns, maxMessages: maxStoredMessages, changed: map[int]bool{}}
+}
+
+// Starts a new run, its messages are added with `RunReporter` or `Add`.
+func (s *MessageStore) BeginRun(operation Operation, roots []string) *StoredRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := &StoredRun{ID: s.nextRun, Operation: string(operation), Roots: roots, Started: time.Now()}
+	s.nextRun++
+	s.runs = append(s.runs, run)
+	s.changed[run.ID] = true
+	s.evict()
+	return run
+}
+
+func (s *MessageStore) FinishRun(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run := s.run(id); run != nil {
+		run.Done = true
+		s.changed[id] = true
+		s.evict()
+	}
+}
+
+func (s *MessageStore) run(id int) *StoredRun {
+	for _, run := range s.runs {
+		if run.ID == id {
+			return run
+		}
+	}
+	return nil
+}
+
+// Adds a message produced by the run, or outside of runs when run is 0 or no longer stored.
+func (s *MessageStore) Add(run int, message *Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored := s.run(run); stored != nil {
+		stored.Count++
+		s.changed[run] = true
+	} else {
+		run = 0
+		s.outside++
+	}
+	s.messages = append(s.messages, &StoredMessage{Index: s.next, Run: run, Message: message})
+	s.next++
+	s.evict()
+}
+
+// Drops the oldest finished runs with their messages while there are too many, then messages
+// produced outside of runs. Runs in progress are never evicted. Requires lock.
+func (s *MessageStore) evict() {
+	for len(s.runs) > s.maxRuns || len(s.messages) > s.maxMessages {
+		i := slices.IndexFunc(s.runs, func(run *StoredRun) bool { return run.Done })
+		if i < 0 {
+			break
+		}
+		id := s.runs[i].ID
+		s.runs = slices.Delete(s.runs, i, i+1)
+		s.messages = slices.DeleteFunc(s.messages, func(stored *StoredMessage) bool { return stored.Run == id })
+		s.removed = append(s.removed, id)
+		delete(s.changed, id)
+	}
+
+	if len(s.messages) > s.maxMessages && s.outside > 0 {
+		over := min(len(s.messages)-s.maxMessages, s.outside)
+		s.outside -= over
+		s.messages = slices.DeleteFunc(s.messages, func(stored *StoredMessage) bool {
+			if over > 0 && stored.Run == 0 {
+				over--
+				return true
+			}
+			return false
+		})
+	}
+}
+
+// Returns what changed since the last call, so listeners don't have to be sent all runs.
+func (s *MessageStore) TakeChanges() *MessageChanges {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := &MessageChanges{Runs: []*StoredRun{}, Removed: s.removed, Total: len(s.messages)}
+	if changes.Removed == nil {
+		changes.Removed = []int{}
+	}
+	for _, run := range s.runs {
+		if s.changed[run.ID] {
+			clone := *run
+			changes.Runs = append(changes.Runs, &clone)
+		}
+	}
+	clear(s.changed)
+	s.removed = nil
+	return changes
+}
+
+// Adds a finished run with all its messages, e.g. one loaded from history.
+func (s *MessageStore) AddRun(summary *Summary, messages []*Message) *StoredRun {
+	run := s.BeginRun(Operation(summary.Operation), summary.Roots)
+	for _, message := range messages {
+		s.Add(run.ID, message)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.Started = summary.Started
+	run.Done = true
+	return run
+}
+
+// Copies of all runs, oldest first.
+func (s *MessageStore) Runs() []*StoredRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]*StoredRun, len(s.runs))
+	for i, run := range s.runs {
+		clone := *run
+		runs[i] = &clone
+	}
+	return runs
+}
+
+// All messages of a run in the order they were added.
+func (s *MessageStore) Messages(run int) []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []*Message
+	for _, stored := range s.messages {
+		if stored.Run == run {
+			messages = append(messages, stored.Message)
+		}
+	}
+	return messages
+}
+
+func (s *MessageStore) Query(query MessageQuery) *MessagePage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page := &MessagePage{Messages: []*StoredMessage{}, Offset: query.Offset}
+	for _, stored := range slices.Backward(s.messages) {
+		if query.Run != 0 && stored.Run != query.Run || !query.Filter.Match(stored.Message) {
+			continue
+		}
+		if page.Total >= query.Offset && (query.Limit <= 0 || len(page.Messages) < query.Limit) {
+			page.Messages = append(page.Messages, stored)
+		}
+		page.Total++
+	}
+	return page
+}
+
+// Drops all messages and finished runs. Runs in progress are kept, but start over empty.
+func (s *MessageStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.outside = 0
+	s.runs = slices.DeleteFunc(s.runs, func(run *StoredRun) bool {
+		if run.Done {
+			s.removed = append(s.removed, run.ID)
+			delete(s.changed, run.ID)
+		}
+		return run.Done
+	})
+	for _, run := range s.runs {
+		run.Count = 0
+		s.changed[run.ID] = true
+	}
+}
+
+// Reporter adding messages to the store under the run. Progress updates are ignored.
+func (s *MessageStore) RunReporter(run int) Reporter {
+	return &storeReporter{store: s, run: run}
+}
+
+type storeReporter struct {
+	store *MessageStore
+	run   int
+}
+
+func (r *storeReporter) Message(message *Message) {
+	r.store.Add(r.run, message)
+}
+
+func (r *storeReporter) Progress(progress *Progress) {}
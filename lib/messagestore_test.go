@@ -0,0 +1,154 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func fileMessage(title string, fixer string, modified bool, variants ...Variant) *Message {
+	message := &Message{Icon: Ptr("file"), Title: title, Fixer: fixer, Modified: modified}
+	for _, variant := range variants {
+		message.Notes = append(message.Notes, Note{Variant: variant})
+	}
+	return message
+}
+
+func pageTitles(page *MessagePage) []string {
+	titles := []string{}
+	for _, stored := range page.Messages {
+		titles = append(titles, stored.Message.Title)
+	}
+	return titles
+}
+
+func TestMessageStoreQueryPages(t *testing.T) {
+	store := NewMessageStore()
+	run := store.BeginRun(OpFix, []string{"root"})
+	for i := 0; i < 5; i++ {
+		store.Add(run.ID, fileMessage(fmt.Sprintf("m%d", i), "vaj", false, Info))
+	}
+	store.Add(0, fileMessage("outside", "", false, Info))
+
+	tests := []struct {
+		query MessageQuery
+		want  []string
+		total int
+	}{
+		{MessageQuery{}, []string{"outside", "m4", "m3", "m2", "m1", "m0"}, 6},
+		{MessageQuery{Limit: 2}, []string{"outside", "m4"}, 6},
+		{MessageQuery{Offset: 2, Limit: 2}, []string{"m3", "m2"}, 6},
+		{MessageQuery{Offset: 5, Limit: 2}, []string{"m0"}, 6},
+		{MessageQuery{Offset: 10}, []string{}, 6},
+		{MessageQuery{Run: run.ID, Limit: 3}, []string{"m4", "m3", "m2"}, 5},
+	}
+	for _, test := range tests {
+		page := store.Query(test.query)
+		if got := pageTitles(page); !slices.Equal(got, test.want) || page.Total != test.total {
+			t.Errorf("%+v: got %v of %d, want %v of %d", test.query, got, page.Total, test.want, test.total)
+		}
+		if page.Offset != test.query.Offset {
+			t.Errorf("%+v: page offset %d", test.query, page.Offset)
+		}
+	}
+
+	if runs := store.Runs(); len(runs) != 1 || runs[0].Count != 5 {
+		t.Errorf("runs %+v, want one with 5 messages", runs)
+	}
+}
+
+func TestMessageStoreQueryFilters(t *testing.T) {
+	store := NewMessageStore()
+	store.Add(0, fileMessage("A/Dress.vaj", "vaj", true, Success))
+	store.Add(0, fileMessage("A/Dress.vap", "vap", false, Warning, Info))
+	store.Add(0, fileMessage("B/Hat.vam", "gender", true, Error))
+	store.Add(0, fileMessage("B/Hat.vaj", "vaj", false, Info))
+
+	tests := []struct {
+		filter MessageFilter
+		want   []string
+	}{
+		{MessageFilter{Variants: []Variant{Error, Warning}}, []string{"B/Hat.vam", "A/Dress.vap"}},
+		{MessageFilter{Variants: []Variant{Info}}, []string{"B/Hat.vaj", "A/Dress.vap"}},
+		{MessageFilter{Fixers: []string{"vaj"}}, []string{"B/Hat.vaj", "A/Dress.vaj"}},
+		{MessageFilter{Path: "dress"}, []string{"A/Dress.vap", "A/Dress.vaj"}},
+		{MessageFilter{Modified: true}, []string{"B/Hat.vam", "A/Dress.vaj"}},
+		{MessageFilter{Fixers: []string{"vaj"}, Modified: true, Path: "a/"}, []string{"A/Dress.vaj"}},
+	}
+	for _, test := range tests {
+		page := store.Query(MessageQuery{Filter: test.filter})
+		if got := pageTitles(page); !slices.Equal(got, test.want) || page.Total != len(test.want) {
+			t.Errorf("%+v: got %v of %d, want %v", test.filter, got, page.Total, test.want)
+		}
+	}
+}
+
+func TestMessageStoreClearKeepsRunsInProgress(t *testing.T) {
+	store := NewMessageStore()
+	done := store.BeginRun(OpFix, nil)
+	store.Add(done.ID, fileMessage("a", "", false, Info))
+	store.FinishRun(done.ID)
+	running := store.BeginRun(OpFix, nil)
+	store.Add(running.ID, fileMessage("b", "", false, Info))
+
+	store.Clear()
+
+	runs := store.Runs()
+	if len(runs) != 1 || runs[0].ID != running.ID || runs[0].Count != 0 {
+		t.Errorf("runs after clear %+v, want only the running one, empty", runs)
+	}
+	if page := store.Query(MessageQuery{}); page.Total != 0 {
+		t.Errorf("%d messages left after clear", page.Total)
+	}
+}
+
+func TestMessageStoreEvictsOldestRuns(t *testing.T) {
+	store := NewMessageStore()
+	store.maxRuns = 3
+	store.maxMessages = 4
+	var ids []int
+	for i := 0; i < 3; i++ {
+		run := store.BeginRun(OpFix, nil)
+		store.Add(run.ID, fileMessage(fmt.Sprintf("r%d-a", i), "", false, Info))
+		store.Add(run.ID, fileMessage(fmt.Sprintf("r%d-b", i), "", false, Info))
+		store.FinishRun(run.ID)
+		ids = append(ids, run.ID)
+	}
+	running := store.BeginRun(OpFix, nil)
+	for i := 0; i < 5; i++ {
+		store.Add(running.ID, fileMessage(fmt.Sprintf("running-%d", i), "", false, Info))
+	}
+
+	runs := store.Runs()
+	if len(runs) != 1 || runs[0].ID != running.ID {
+		t.Errorf("runs %+v, want only the running one", runs)
+	}
+	if page := store.Query(MessageQuery{}); page.Total != 5 {
+		t.Errorf("%d messages stored, want the 5 of the running run", page.Total)
+	}
+	changes := store.TakeChanges()
+	if !slices.Equal(changes.Removed, ids) {
+		t.Errorf("removed runs %v, want %v", changes.Removed, ids)
+	}
+	if len(changes.Runs) != 1 || changes.Runs[0].ID != running.ID || changes.Runs[0].Count != 5 {
+		t.Errorf("changed runs %+v, want the running one with 5 messages", changes.Runs)
+	}
+}
+
+func TestMessageStoreTakeChanges(t *testing.T) {
+	store := NewMessageStore()
+	first := store.BeginRun(OpFix, nil)
+	store.Add(first.ID, fileMessage("a", "", false, Info))
+	store.FinishRun(first.ID)
+	store.TakeChanges()
+
+	second := store.BeginRun(OpFix, nil)
+	store.Add(second.ID, fileMessage("b", "", false, Info))
+	changes := store.TakeChanges()
+	if len(changes.Runs) != 1 || changes.Runs[0].ID != second.ID || changes.Total != 2 {
+		t.Errorf("changes %+v, want only the second run of 2 messages", changes)
+	}
+	if changes := store.TakeChanges(); len(changes.Runs) != 0 || len(changes.Removed) != 0 {
+		t.Errorf("changes %+v, want none", changes)
+	}
+}
@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Profile used when config doesn't name one.
+const DefaultProfile = "default"
+
+var ErrProfileNotFound = errors.New("profile doesn't exist")
+var ErrProfileExists = errors.New("profile already exists")
+
+var profileNameExp = regexp.MustCompile(`^[\w-][\w .-]*$`)
+
+// Profile names are used as file names, so only letters, numbers, spaces, dots, dashes, and underscores are allowed.
+func ValidateProfileName(name string) error {
+	if len(name) > 64 || !profileNameExp.MatchString(name) {
+		return fmt.Errorf("invalid profile name \"%s\"", name)
+	}
+	return nil
+}
+
+// Name of the profile the config belongs to.
+func (c *AppConfig) ActiveProfile() string {
+	if c.Profile == "" {
+		return DefaultProfile
+	}
+	return c.Profile
+}
+
+// Deep copy of the config.
+func (c *AppConfig) Clone() *AppConfig {
+	clone := *c
+	if c.Disabled != nil {
+		clone.Disabled = make(map[Operation][]string, len(c.Disabled))
+		for operation, names := range c.Disabled {
+			clone.Disabled[operation] = slices.Clone(names)
+		}
+	}
+	clone.Severity = maps.Clone(c.Severity)
+	clone.Include = slices.Clone(c.Include)
+	clone.Exclude = slices.Clone(c.Exclude)
+	return &clone
+}
+
+// Stores named app configs as `<name>.json` files in a directory.
+type ProfileStore struct {
+	Dir string
+}
+
+func NewProfileStore(dir string) *ProfileStore {
+	return &ProfileStore{Dir: dir}
+}
+
+func (p *ProfileStore) store(name string) *ConfigStore {
+	return NewAppConfigStore(filepath.Join(p.Dir, name+".json"))
+}
+
+// Sorted names of all stored profiles.
+func (p *ProfileStore) List() ([]string, error) {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if ok && !entry.IsDir() && ValidateProfileName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (p *ProfileStore) Exists(name string) bool {
+	_, err := os.Stat(p.store(name).Path)
+	return err == nil
+}
+
+func (p *ProfileStore) Load(name string) (*AppConfig, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if !p.Exists(name) {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrProfileNotFound, name)
+	}
+	config := NewAppConfig()
+	if err := p.store(name).Load(config); err != nil {
+		return nil, err
+	}
+	config.Profile = name
+	return config, nil
+}
+
+// Saves a copy of the config as the named profile, overwriting an existing one.
+func (p *ProfileStore) Save(name string, config *AppConfig) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p.Dir, 0755); err != nil {
+		return err
+	}
+	config = config.Clone()
+	config.Version = AppConfigVersion
+	config.Profile = name
+	return p.store(name).Save(config)
+}
+
+func (p *ProfileStore) Delete(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	err := os.Remove(p.store(name).Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: \"%s\"", ErrProfileNotFound, name)
+	}
+	return err
+}
+
+// Writes the config to a file that can be shared and imported with `ImportProfile`.
+func ExportProfile(config *AppConfig, filePath string) error {
+	config = config.Clone()
+	config.Version = AppConfigVersion
+	config.Profile = config.ActiveProfile()
+	return NewAppConfigStore(filePath).Save(config)
+}
+
+// Reads an exported profile. When it doesn't name a profile, file name is used.
+func ImportProfile(filePath string) (*AppConfig, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, err
+	}
+	config := NewAppConfig()
+	if err := NewAppConfigStore(filePath).Load(config); err != nil {
+		return nil, err
+	}
+	if config.Profile == "" {
+		config.Profile = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+	if err := ValidateProfileName(config.Profile); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
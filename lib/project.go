@@ -0,0 +1,142 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// Name of the project config file in the root of a package directory inside AddonPackagesBuilder.
+const ProjectConfigFileName = "cpu.json"
+
+var packageRootExp = regexp.MustCompile(`(?i)^(.*/AddonPackagesBuilder/[^/]+\.var)(?:/|$)`)
+
+// Returns root directory of the AddonPackagesBuilder package the path is in.
+func GetPackageRoot(filePath string) (root string, found bool) {
+	matches := packageRootExp.FindStringSubmatch(filePath)
+	if matches == nil {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// Config of a single package, app config with package's project config merged over it.
+type ProjectConfig struct {
+	// Path to the project config file, empty when package has none.
+	Path   string
+	Config *AppConfig
+	// Patterns of the project config file relative to package root.
+	Include IgnoreList
+	Exclude IgnoreList
+	// Error encountered when loading the project config file.
+	Err error
+}
+
+// Loads and caches project configs of packages, safe for concurrent use.
+type ProjectConfigs struct {
+	mu       sync.Mutex
+	base     *AppConfig
+	packages map[string]*ProjectConfig
+}
+
+func NewProjectConfigs(base *AppConfig) *ProjectConfigs {
+	return &ProjectConfigs{base: base, packages: map[string]*ProjectConfig{}}
+}
+
+// Returns project config of the package the path is in, or nil when not in a package.
+func (p *ProjectConfigs) Get(filePath string) *ProjectConfig {
+	root, ok := GetPackageRoot(filePath)
+	if !ok {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	project, ok := p.packages[root]
+	if !ok {
+		project = loadProjectConfig(root, p.base)
+		p.packages[root] = project
+	}
+	return project
+}
+
+// Config the path should be processed with.
+func (p *ProjectConfigs) ConfigFor(filePath string) *AppConfig {
+	if project := p.Get(filePath); project != nil {
+		return project.Config
+	}
+	return p.base
+}
+
+// Paths of all project config files that were applied.
+func (p *ProjectConfigs) Applied() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	applied := []string{}
+	for _, project := range p.packages {
+		if project.Path != "" && project.Err == nil {
+			applied = append(applied, project.Path)
+		}
+	}
+	slices.Sort(applied)
+	return applied
+}
+
+func loadProjectConfig(root string, base *AppConfig) *ProjectConfig {
+	project := &ProjectConfig{Config: base}
+	filePath := path.Join(root, ProjectConfigFileName)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			project.Path = filePath
+			project.Err = err
+		}
+		return project
+	}
+	project.Path = filePath
+
+	own := &AppConfig{}
+	if err := json.Unmarshal(data, own); err != nil {
+		project.Err = err
+		return project
+	}
+
+	merged, err := base.Merge(data)
+	if err == nil {
+		err = merged.Validate()
+	}
+	if err != nil {
+		project.Err = err
+		return project
+	}
+
+	project.Config = merged
+	project.Include = NewIgnoreList(root, own.Include)
+	project.Exclude = NewIgnoreList(root, own.Exclude)
+	return project
+}
+
+// Returns a copy of the config with JSON encoded config data merged over it.
+// Only properties present in data are overwritten, maps are merged key by key.
+func (c *AppConfig) Merge(data []byte) (*AppConfig, error) {
+	baseData, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	merged := &AppConfig{}
+	if err := json.Unmarshal(baseData, merged); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
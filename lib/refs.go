@@ -0,0 +1,170 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Resource references, either to the package itself (`SELF:/`), another package
+// (`Author.Package.version:/`), or a local path in VaM's directory (`Custom/`).
+var packageRefExp = regexp.MustCompile(`^(SELF|([^/:.]+\.[^/:.]+)\.(latest|min\d+|\d+)):/(.+)$`)
+var localRefExp = regexp.MustCompile(`(?i)^/?(Custom/.+)$`)
+
+var packageVarExp = regexp.MustCompile(`^([^/.]+\.[^/.]+)\.(\d+)\.var$`)
+
+// Checks that files referenced by a package file exist in the package, and that packages it
+// depends on are installed in `AddonPackages`, next to `AddonPackagesBuilder`.
+func FixReferences(ctx context.Context, filePath string) *Message {
+	root, ok := GetPackageRoot(filePath)
+	if !ok {
+		return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+			Variant: "danger", Rule: "not-prepped", Code: "REF001", Text: "File is not inside a package directory in AddonPackagesBuilder.",
+		}}}
+	}
+	_, ownName, _, _ := getPreppedPackageName(filePath)
+	vamRoot := filepath.ToSlash(filepath.Dir(filepath.Dir(root)))
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+			Variant: "danger", Rule: "read-failed", Code: "REF002", Text: "Couldn't read file.", Details: Ptr(err.Error()),
+		}}}
+	}
+	if !gjson.ValidBytes(data) {
+		return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+			Variant: "danger", Rule: "invalid-json", Code: "REF003", Text: "Can't parse JSON (invalid).",
+		}}}
+	}
+
+	var notes []Note
+	checked := 0
+	walkJSONStrings(gjson.ParseBytes(data), "", func(jsonPath string, value string) {
+		if matches := packageRefExp.FindStringSubmatch(value); matches != nil {
+			name, version, file := matches[2], matches[3], matches[4]
+			checked++
+			if matches[1] == "SELF" || name == ownName {
+				if !fileExists(path.Join(root, file)) {
+					notes = append(notes, Note{Variant: "danger", Rule: "missing-file", Code: "REF004", Path: jsonPath}.
+						WithSegments(Plain("Referenced file "), PathSegment(file), Plain(" isn't in the package.")))
+				}
+				return
+			}
+			installed, listed := packageInstalled(path.Join(vamRoot, "AddonPackages"), name, version)
+			if listed && !installed {
+				notes = append(notes, Note{Variant: "warning", Rule: "dependency-missing", Code: "REF005", Path: jsonPath}.
+					WithSegments(Plain("Package "), Code(name+"."+version), Plain(" isn't installed in "), PathSegment("AddonPackages"), Plain(".")))
+			}
+			return
+		}
+		if matches := localRefExp.FindStringSubmatch(value); matches != nil {
+			checked++
+			if !fileExists(path.Join(root, matches[1])) && !fileExists(path.Join(vamRoot, matches[1])) {
+				notes = append(notes, Note{Variant: "danger", Rule: "missing-file", Code: "REF004", Path: jsonPath}.
+					WithSegments(Plain("Referenced file "), PathSegment(matches[1]), Plain(" isn't in the package or VaM directory.")))
+			}
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return cancelledMessage(filePath, "REF007", err)
+	}
+	if len(notes) == 0 {
+		notes = append(notes, Note{Variant: "info", Rule: "references-ok", Code: "REF006", Text: fmt.Sprintf("All %d references resolve.", checked)})
+	}
+	return &Message{Icon: Ptr("file"), Title: filePath, Notes: notes}
+}
+
+// Calls visit with every string value in the JSON, along with its gjson path.
+func walkJSONStrings(value gjson.Result, jsonPath string, visit func(jsonPath string, value string)) {
+	join := func(key string) string {
+		if jsonPath == "" {
+			return key
+		}
+		return jsonPath + "." + key
+	}
+	switch {
+	case value.IsArray():
+		for i, item := range value.Array() {
+			walkJSONStrings(item, join(strconv.Itoa(i)), visit)
+		}
+	case value.IsObject():
+		value.ForEach(func(key, item gjson.Result) bool {
+			walkJSONStrings(item, join(gjsonPathEscaper.Replace(key.String())), visit)
+			return true
+		})
+	case value.Type == gjson.String:
+		visit(jsonPath, value.String())
+	}
+}
+
+var gjsonPathEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `*`, `\*`, `?`, `\?`, `|`, `\|`, `#`, `\#`, `@`, `\@`)
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
+
+// How long listings of installed packages are reused, so each validated file doesn't walk them again.
+const installedPackagesTTL = 5 * time.Second
+
+type installedPackages struct {
+	listed   time.Time
+	versions map[string][]int
+}
+
+var installedPackagesMu sync.Mutex
+var installedPackagesCache = map[string]*installedPackages{}
+
+// Whether a package satisfying the version (`latest`, `minN`, or exact `N`) is in the directory.
+// Listed is false when the directory doesn't exist, so dependencies can't be checked.
+func packageInstalled(dir string, name string, version string) (installed bool, listed bool) {
+	installedPackagesMu.Lock()
+	defer installedPackagesMu.Unlock()
+
+	packages, ok := installedPackagesCache[dir]
+	if !ok || time.Since(packages.listed) > installedPackagesTTL {
+		packages = &installedPackages{listed: time.Now(), versions: map[string][]int{}}
+		err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if matches := packageVarExp.FindStringSubmatch(entry.Name()); matches != nil {
+				if version, err := strconv.Atoi(matches[2]); err == nil {
+					packages.versions[matches[1]] = append(packages.versions[matches[1]], version)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return false, false
+		}
+		installedPackagesCache[dir] = packages
+	}
+
+	for _, installed := range packages.versions[name] {
+		switch {
+		case version == NamespaceLatest:
+			return true, true
+		case strings.HasPrefix(version, NamespaceMin):
+			if min, _ := strconv.Atoi(strings.TrimPrefix(version, NamespaceMin)); installed >= min {
+				return true, true
+			}
+		default:
+			if exact, _ := strconv.Atoi(version); installed == exact {
+				return true, true
+			}
+		}
+	}
+	return false, true
+}
@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"context"
+	"path"
+	"slices"
+	"testing"
+)
+
+func TestFixReferences(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"AddonPackages/Other.Pack.3.var":                      "",
+		"Custom/Scripts/Local.cs":                             "",
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Tex/a.png":  "",
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Own/b.json": "",
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Item.vaj": `{"storables": [{
+			"self": "SELF:/Custom/Tex/a.png",
+			"selfMissing": "SELF:/Custom/Tex/missing.png",
+			"own": "Me.Pkg.latest:/Custom/Own/b.json",
+			"latest": "Other.Pack.latest:/x.png",
+			"min": "Other.Pack.min2:/x.png",
+			"minMissing": "Other.Pack.min4:/x.png",
+			"exact": "Other.Pack.3:/x.png",
+			"exactMissing": "Other.Pack.2:/x.png",
+			"absent": "Nobody.Pack.1:/x.png",
+			"local": "Custom/Scripts/Local.cs",
+			"localMissing": "Custom/Scripts/Missing.cs",
+			"text": "not a reference"
+		}]}`,
+	})
+
+	message := FixReferences(context.Background(), path.Join(root, "AddonPackagesBuilder/Me.Pkg.1.var/Custom/Item.vaj"))
+
+	got := []string{}
+	for _, note := range message.Notes {
+		got = append(got, note.Code+" "+note.Path)
+	}
+	want := []string{
+		"REF004 storables.0.selfMissing",
+		"REF005 storables.0.minMissing",
+		"REF005 storables.0.exactMissing",
+		"REF005 storables.0.absent",
+		"REF004 storables.0.localMissing",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got notes:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestFixReferencesOk(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/a.png":    "",
+		"AddonPackagesBuilder/Me.Pkg.1.var/Custom/Item.vaj": `{"a": "SELF:/Custom/a.png", "b": "Custom/a.png"}`,
+	})
+
+	message := FixReferences(context.Background(), path.Join(root, "AddonPackagesBuilder/Me.Pkg.1.var/Custom/Item.vaj"))
+
+	if len(message.Notes) != 1 || message.Notes[0].Code != "REF006" || message.Notes[0].Text != "All 2 references resolve." {
+		t.Errorf("got notes %+v, want REF006 for 2 references", message.Notes)
+	}
+}
+
+func TestFixReferencesOutsidePackage(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{"Custom/Item.vaj": `{}`})
+	message := FixReferences(context.Background(), path.Join(root, "Custom/Item.vaj"))
+	if len(message.Notes) != 1 || message.Notes[0].Code != "REF001" {
+		t.Errorf("got notes %+v, want REF001", message.Notes)
+	}
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// A single check/fix applied to files matching its path.
+type Fixer interface {
+	// Unique name used in configs and messages.
+	Name() string
+	// Short description displayed in the UI.
+	Description() string
+	// Whether the fixer handles the file. Requires `path` normalized to forward slashes.
+	Match(path string) bool
+	// Operations the fixer runs in.
+	Modes() []Operation
+	// Bumped whenever the fixer starts checking or fixing something new, so files validated by
+	// previous versions are processed again by incremental runs.
+	Version() int
+	Fix(ctx context.Context, file *File) *Message
+}
+
+// Implemented by fixers whose results depend on files other than the one they fix, so incremental
+// runs process the file again when those change.
+type DependentFixer interface {
+	// Other files the fix reads. When they can't be listed, ok is false and the file is always processed.
+	Dependencies(file *File) (paths []string, ok bool)
+}
+
+// File being processed by fixers.
+type File struct {
+	// Normalized to forward slashes.
+	Path      string
+	Operation Operation
+	// App config merged with project config of the package the file is in.
+	Config *AppConfig
+}
+
+// Fixer description for the UI.
+type FixerInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Modes       []Operation `json:"modes"`
+	Version     int         `json:"version"`
+}
+
+var registry []Fixer
+
+// Adds the fixer to the list of fixers operations iterate. Fixers run in the order they were registered.
+func RegisterFixer(fixer Fixer) {
+	if _, exists := GetFixer(fixer.Name()); exists {
+		panic(fmt.Sprintf("fixer \"%s\" is already registered", fixer.Name()))
+	}
+	registry = append(registry, fixer)
+}
+
+func GetFixer(name string) (Fixer, bool) {
+	return Find(registry, func(fixer Fixer) bool { return fixer.Name() == name })
+}
+
+func Fixers() []Fixer {
+	return slices.Clone(registry)
+}
+
+func ListFixers() []FixerInfo {
+	infos := make([]FixerInfo, 0, len(registry))
+	for _, fixer := range registry {
+		infos = append(infos, FixerInfo{Name: fixer.Name(), Description: fixer.Description(), Modes: fixer.Modes(), Version: fixer.Version()})
+	}
+	return infos
+}
+
+// Fixer implementation backed by a path regexp and a fix function.
+type funcFixer struct {
+	name        string
+	description string
+	modes       []Operation
+	version     int
+	exps        []*regexp.Regexp
+	fix         func(ctx context.Context, file *File) *Message
+	// Lists other files the fix reads, nil when it reads only the file itself.
+	dependencies func(file *File) ([]string, bool)
+}
+
+func (f *funcFixer) Name() string        { return f.name }
+func (f *funcFixer) Description() string { return f.description }
+func (f *funcFixer) Modes() []Operation  { return f.modes }
+func (f *funcFixer) Version() int        { return f.version }
+
+func (f *funcFixer) Match(path string) bool {
+	for _, exp := range f.exps {
+		if exp.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *funcFixer) Fix(ctx context.Context, file *File) *Message {
+	return f.fix(ctx, file)
+}
+
+func (f *funcFixer) Dependencies(file *File) ([]string, bool) {
+	if f.dependencies == nil {
+		return nil, true
+	}
+	return f.dependencies(file)
+}
+
+var clothingVajExp = regexp.MustCompile(`(?i).*/custom/clothing/(?:female|male)/[^/]+/[^/]+/.*\.vaj$`)
+var clothingVapExps = []*regexp.Regexp{
+	regexp.MustCompile(`(?i).*/custom/clothing/(?:female|male)/[^/]+/[^/]+/.*\.vap$`),
+	regexp.MustCompile(`(?i).*/custom/atom/person/appearance/.*\.vap$`),
+	regexp.MustCompile(`(?i).*/custom/atom/person/clothing/.*\.vap$`),
+}
+var clothingCplExp = regexp.MustCompile(`(?i).*/custom/clothing/(?:female|male)/[^/]+/[^/]+/.*\.clothingplugins$`)
+var packageJSONExp = regexp.MustCompile(`(?i).*/AddonPackagesBuilder/[^/]+\.var/.*\.(?:vaj|vap|vam|json|clothingplugins)$`)
+
+func init() {
+	RegisterFixer(&funcFixer{
+		name:        "gender",
+		description: "Sets hair & clothing item types to the gender matching the directory they are in.",
+		modes:       []Operation{OpFix, OpGender},
+		version:     1,
+		exps:        []*regexp.Regexp{ItemGenderExp},
+		fix: func(ctx context.Context, file *File) *Message {
+			return FixItemGender(ctx, file.Path)
+		},
+	})
+	RegisterFixer(&funcFixer{
+		name:        "vaj",
+		description: "Initializes Clothing Plugin Manager in .vaj files, or ensures it's set up properly.",
+		modes:       []Operation{OpInit, OpFix},
+		version:     1,
+		exps:        []*regexp.Regexp{clothingVajExp},
+		fix: func(ctx context.Context, file *File) *Message {
+			return FixVaj(ctx, file.Path, file.Operation != OpInit)
+		},
+		// The item's UID is read from its .vam file
+		dependencies: func(file *File) ([]string, bool) {
+			vamPath, err := findVamFile(file.Path)
+			return []string{vamPath}, err == nil
+		},
+	})
+	RegisterFixer(&funcFixer{
+		name:        "cpl",
+		description: "Namespaces relative paths in .clothingplugins files to the package.",
+		modes:       []Operation{OpFix},
+		version:     1,
+		exps:        []*regexp.Regexp{clothingCplExp},
+		fix: func(ctx context.Context, file *File) *Message {
+			return FixCpl(ctx, file.Path, file.Config.NamespaceVersion)
+		},
+	})
+	RegisterFixer(&funcFixer{
+		name:        "vap",
+		description: "Namespaces relative paths in Clothing Plugin Manager storables of .vap presets to the package.",
+		modes:       []Operation{OpFix},
+		version:     1,
+		exps:        clothingVapExps,
+		fix: func(ctx context.Context, file *File) *Message {
+			return FixVap(ctx, file.Path, file.Config.NamespaceVersion)
+		},
+	})
+	RegisterFixer(&funcFixer{
+		name:        "refs",
+		description: "Checks that files referenced by package files exist, and packages they depend on are installed.",
+		modes:       []Operation{OpValidate},
+		version:     1,
+		exps:        []*regexp.Regexp{packageJSONExp},
+		fix: func(ctx context.Context, file *File) *Message {
+			return FixReferences(ctx, file.Path)
+		},
+		// Referenced files and installed packages can change at any time
+		dependencies: func(file *File) ([]string, bool) {
+			return nil, false
+		},
+	})
+}
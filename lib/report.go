@@ -0,0 +1,261 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ReportFormat string
+
+const (
+	// Self-contained page with inlined styles.
+	ReportHTML ReportFormat = "html"
+	// Suitable for pasting into release notes or hub threads.
+	ReportMarkdown ReportFormat = "markdown"
+	// Messages and summaries as emitted, for tooling.
+	ReportJSON ReportFormat = "json"
+	// SARIF 2.1.0 log for static analysis dashboards.
+	ReportSARIF ReportFormat = "sarif"
+	// JUnit XML for CI test result dashboards.
+	ReportJUnit ReportFormat = "junit"
+)
+
+var ReportFormats = []ReportFormat{ReportHTML, ReportMarkdown, ReportJSON, ReportSARIF, ReportJUnit}
+
+// Picks the report format by file extension.
+func ReportFormatFromPath(filePath string) (ReportFormat, error) {
+	name := strings.ToLower(filepath.Base(filePath))
+	if strings.HasSuffix(name, ".sarif") || strings.HasSuffix(name, ".sarif.json") {
+		return ReportSARIF, nil
+	}
+	switch filepath.Ext(name) {
+	case ".html", ".htm":
+		return ReportHTML, nil
+	case ".md", ".markdown":
+		return ReportMarkdown, nil
+	case ".json":
+		return ReportJSON, nil
+	case ".xml":
+		return ReportJUnit, nil
+	}
+	return "", fmt.Errorf("unknown report format of \"%s\", use .html, .md, .json, .sarif, or .xml (JUnit)", filePath)
+}
+
+// Messages of one or more runs, along with their summaries.
+type Report struct {
+	Generated time.Time  `json:"generated"`
+	Summaries []*Summary `json:"summaries"`
+	Messages  []*Message `json:"messages"`
+}
+
+func NewReport(messages []*Message) *Report {
+	report := &Report{Generated: time.Now(), Summaries: []*Summary{}, Messages: messages}
+	for _, message := range messages {
+		if message.Summary != nil {
+			report.Summaries = append(report.Summaries, message.Summary)
+		}
+	}
+	return report
+}
+
+// Writes the report into a file in the format matching its extension.
+func (r *Report) WriteFile(filePath string) error {
+	format, err := ReportFormatFromPath(filePath)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (r *Report) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportHTML:
+		return reportTemplate.Execute(w, r)
+	case ReportMarkdown:
+		_, err := io.WriteString(w, r.markdown())
+		return err
+	case ReportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(r)
+	case ReportSARIF:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(r.sarif())
+	case ReportJUnit:
+		return r.writeJUnit(w)
+	}
+	return fmt.Errorf("unknown report format \"%s\"", format)
+}
+
+// Summary messages come first, followed by file messages in the order they were emitted.
+func (r *Report) sections() (summaries []*Message, files []*Message) {
+	for _, message := range r.Messages {
+		if message.Summary != nil {
+			summaries = append(summaries, message)
+		} else {
+			files = append(files, message)
+		}
+	}
+	return summaries, files
+}
+
+var variantMarkdownIcons = map[Variant]string{
+	Success: "✅",
+	Warning: "⚠️",
+	Error:   "❌",
+	Info:    "ℹ️",
+}
+
+func (r *Report) markdown() string {
+	var md strings.Builder
+	summaries, files := r.sections()
+
+	md.WriteString("# Clothing Plugins Util report\n\n")
+	md.WriteString("Generated " + r.Generated.Format(time.RFC1123) + ".\n")
+
+	writeMessage := func(message *Message) {
+		md.WriteString("\n### " + markdownEscape(message.Title) + "\n\n")
+		for _, note := range message.Notes {
+			md.WriteString("- " + variantMarkdownIcons[note.Variant] + " " + note.RichText().Markdown() + "\n")
+			if note.Details != nil {
+				md.WriteString("\n  ```\n")
+				for _, line := range strings.Split(strings.TrimRight(*note.Details, "\n"), "\n") {
+					md.WriteString("  " + line + "\n")
+				}
+				md.WriteString("  ```\n")
+			}
+		}
+	}
+
+	if len(summaries) > 0 {
+		md.WriteString("\n## Summary\n")
+		for _, message := range summaries {
+			writeMessage(message)
+		}
+	}
+	if len(files) > 0 {
+		md.WriteString("\n## Files\n")
+		for _, message := range files {
+			writeMessage(message)
+		}
+	}
+
+	return md.String()
+}
+
+var markdownSpecialExp = regexp.MustCompile("([\\\\`*_\\[\\]<>#|])")
+
+func markdownEscape(text string) string {
+	return markdownSpecialExp.ReplaceAllString(text, "\\$1")
+}
+
+// Identifies the check that produced the note across runs. Its code when it has one,
+// otherwise `fixer/rule` when both are known.
+func noteRuleID(message *Message, note *Note) string {
+	switch {
+	case note.Code != "":
+		return note.Code
+	case message.Fixer != "" && note.Rule != "":
+		return message.Fixer + "/" + note.Rule
+	case note.Rule != "":
+		return note.Rule
+	case message.Fixer != "":
+		return message.Fixer
+	}
+	return "general"
+}
+
+// Whether the message is about a file or directory, and its title is the path.
+func isFileMessage(message *Message) bool {
+	return message.Summary == nil && message.Icon != nil && *message.Icon == "file"
+}
+
+// Paths of files with error or warning notes in messages, in the order they were first reported.
+func FailedFiles(messages []*Message) []string {
+	files := []string{}
+	for _, message := range messages {
+		if !isFileMessage(message) || slices.Contains(files, message.Title) {
+			continue
+		}
+		if slices.ContainsFunc(message.Notes, func(note Note) bool { return note.Variant == Error || note.Variant == Warning }) {
+			files = append(files, message.Title)
+		}
+	}
+	return files
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sections": func(r *Report) map[string][]*Message {
+		summaries, files := r.sections()
+		return map[string][]*Message{"summaries": summaries, "files": files}
+	},
+	// Segment texts are escaped by the renderer
+	"noteHTML": func(note Note) template.HTML { return template.HTML(note.RichText().HTML()) },
+	"date":     func(t time.Time) string { return t.Format(time.RFC1123) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Clothing Plugins Util report</title>
+<style>
+	body { margin: 0 auto; max-width: 60em; padding: 1em; font: 14px/1.4 sans-serif; background: #232025; color: #e8e4ea; }
+	h1 { font-size: 1.5em; }
+	h2 { font-size: 1.2em; margin-top: 2em; }
+	article { margin: .5em 0; padding: .5em 1em; border-radius: .3em; background: #302c33; }
+	article > h3 { margin: 0; font-size: 1em; word-break: break-all; }
+	ul { margin: .3em 0 0; padding: 0; list-style: none; }
+	li { padding: .1em 0 .1em .8em; border-left: 3px solid #888; margin: .2em 0; }
+	li.-success { border-color: #5c5; }
+	li.-warning { border-color: #eb4; }
+	li.-danger { border-color: #e55; }
+	li.-info { border-color: #59e; }
+	code { background: #0004; border-radius: .3em; padding: 0 .2em; }
+	pre { margin: .3em 0; padding: .5em; background: #0004; border-radius: .3em; overflow-x: auto; }
+	.date { opacity: .7; }
+</style>
+</head>
+<body>
+<h1>Clothing Plugins Util report</h1>
+<p class="date">Generated {{date .Generated}}</p>
+{{- with sections .}}
+{{- if .summaries}}
+<h2>Summary</h2>
+{{- range .summaries}}{{template "message" .}}{{end}}
+{{- end}}
+{{- if .files}}
+<h2>Files</h2>
+{{- range .files}}{{template "message" .}}{{end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{define "message"}}
+<article>
+	<h3 title="{{.Title}}">{{.Title}}</h3>
+	<ul>
+	{{- range .Notes}}
+		<li class="-{{.Variant}}">{{noteHTML .}}{{if .Details}}<pre>{{.Details}}</pre>{{end}}</li>
+	{{- end}}
+	</ul>
+</article>
+{{- end}}
+`))
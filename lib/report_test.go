@@ -0,0 +1,47 @@
+package lib
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFailedFiles(t *testing.T) {
+	summary := &Message{Icon: Ptr("summary"), Title: "Summary (fix)", Summary: NewSummary("fix", nil), Notes: []Note{{Variant: Error}}}
+	messages := []*Message{
+		fileMessage("a.vaj", "vaj", false, Info),
+		fileMessage("b.vaj", "vaj", false, Info, Warning),
+		fileMessage("c.vap", "vap", true, Error),
+		// Same file reported by another fixer, listed once
+		fileMessage("b.vaj", "refs", false, Error),
+		fileMessage("d.vam", "gender", true, Success),
+		// Not about a file
+		{Title: "Operation cancelled", Notes: []Note{{Variant: Warning}}},
+		summary,
+	}
+
+	want := []string{"b.vaj", "c.vap"}
+	if got := FailedFiles(messages); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := FailedFiles(nil); got == nil || len(got) != 0 {
+		t.Errorf("got %v for no messages, want empty list", got)
+	}
+}
+
+func TestWriteJUnitOneFailurePerCase(t *testing.T) {
+	message := fileMessage("a.vaj", "vaj", false, Error, Warning, Info)
+	message.Notes[0].Code = "VAJ003"
+	message.Notes[1].Code = "VAJ001"
+	builder := &strings.Builder{}
+	if err := NewReport([]*Message{message}).writeJUnit(builder); err != nil {
+		t.Fatal(err)
+	}
+	output := builder.String()
+	if count := strings.Count(output, "<failure "); count != 1 {
+		t.Errorf("%d failures in the test case, want 1:\n%s", count, output)
+	}
+	if !strings.Contains(output, "VAJ003") || !strings.Contains(output, "VAJ001") || !strings.Contains(output, `failures="1"`) {
+		t.Errorf("failure doesn't list all problems:\n%s", output)
+	}
+}
@@ -0,0 +1,178 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Receives everything an operation emits. Implementations have to be safe for concurrent use.
+type Reporter interface {
+	Message(message *Message)
+	Progress(progress *Progress)
+}
+
+// Writes each message and progress update as a single line of JSON.
+type JSONLinesReporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+type jsonLine struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+func NewJSONLinesReporter(w io.Writer) *JSONLinesReporter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &JSONLinesReporter{encoder: encoder}
+}
+
+func (r *JSONLinesReporter) Message(message *Message) {
+	r.write("message", message)
+}
+
+func (r *JSONLinesReporter) Progress(progress *Progress) {
+	r.write("progress", progress)
+}
+
+func (r *JSONLinesReporter) write(kind string, data any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.encoder.Encode(jsonLine{Type: kind, Data: data})
+}
+
+// Writes each message and progress update as a server-sent event named by its type, e.g. for
+// browsers' `EventSource`.
+type EventStreamReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewEventStreamReporter(w io.Writer) *EventStreamReporter {
+	return &EventStreamReporter{w: w}
+}
+
+func (r *EventStreamReporter) Message(message *Message) {
+	r.write("message", message)
+}
+
+func (r *EventStreamReporter) Progress(progress *Progress) {
+	r.write("progress", progress)
+}
+
+func (r *EventStreamReporter) write(kind string, data any) {
+	encoded, err := JSONMarshalCompact(data)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	io.WriteString(r.w, "event: "+kind+"\ndata: "+string(encoded)+"\n\n")
+}
+
+// Writes messages as human readable text, progress updates are ignored. Formatting and variant
+// colors use ANSI escape codes when color is enabled.
+type TextReporter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	color bool
+}
+
+func NewTextReporter(w io.Writer, color bool) *TextReporter {
+	return &TextReporter{w: w, color: color}
+}
+
+var variantANSIColors = map[Variant]string{
+	Success: "\x1b[32m",
+	Warning: "\x1b[33m",
+	Error:   "\x1b[31m",
+	Info:    "\x1b[34m",
+}
+
+func (r *TextReporter) Message(message *Message) {
+	var text strings.Builder
+	if r.color {
+		text.WriteString(ansiBold + message.Title + ansiReset + "\n")
+	} else {
+		text.WriteString(message.Title + "\n")
+	}
+	for _, note := range message.Notes {
+		label := fmt.Sprintf("%-7s", note.Variant)
+		if note.Code != "" {
+			label += " " + note.Code
+		}
+		if r.color {
+			text.WriteString("  " + variantANSIColors[note.Variant] + label + ansiReset + "  " + note.RichText().ANSI() + "\n")
+		} else {
+			text.WriteString("  " + label + "  " + note.Text + "\n")
+		}
+		if note.Details != nil {
+			for _, line := range strings.Split(strings.TrimRight(*note.Details, "\n"), "\n") {
+				text.WriteString("      " + line + "\n")
+			}
+		}
+	}
+	text.WriteString("\n")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	io.WriteString(r.w, text.String())
+}
+
+func (r *TextReporter) Progress(progress *Progress) {}
+
+// Collects everything in memory.
+type MemoryReporter struct {
+	mu       sync.Mutex
+	messages []*Message
+	progress *Progress
+}
+
+func NewMemoryReporter() *MemoryReporter {
+	return &MemoryReporter{}
+}
+
+func (r *MemoryReporter) Message(message *Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+}
+
+func (r *MemoryReporter) Progress(progress *Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress = progress
+}
+
+// Returns a copy of all collected messages.
+func (r *MemoryReporter) Messages() []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Message{}, r.messages...)
+}
+
+// Returns the last received progress update.
+func (r *MemoryReporter) LastProgress() *Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress
+}
+
+// Forwards everything to multiple reporters.
+type MultiReporter []Reporter
+
+func (r MultiReporter) Message(message *Message) {
+	for _, reporter := range r {
+		reporter.Message(message)
+	}
+}
+
+func (r MultiReporter) Progress(progress *Progress) {
+	for _, reporter := range r {
+		reporter.Progress(progress)
+	}
+}
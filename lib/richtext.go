@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"html"
+	"strings"
+)
+
+type SegmentKind string
+
+const (
+	SegmentPlain SegmentKind = "plain"
+	// Property names, values, and other literals.
+	SegmentCode     SegmentKind = "code"
+	SegmentEmphasis SegmentKind = "emphasis"
+	// File or directory path.
+	SegmentPath SegmentKind = "path"
+)
+
+// Piece of note text with a single kind of formatting.
+type Segment struct {
+	Kind SegmentKind `json:"kind"`
+	Text string      `json:"text"`
+}
+
+func Plain(text string) Segment       { return Segment{Kind: SegmentPlain, Text: text} }
+func Code(text string) Segment        { return Segment{Kind: SegmentCode, Text: text} }
+func Emphasis(text string) Segment    { return Segment{Kind: SegmentEmphasis, Text: text} }
+func PathSegment(text string) Segment { return Segment{Kind: SegmentPath, Text: text} }
+
+// Formatted text, rendered differently depending on where it's displayed.
+type RichText []Segment
+
+// Returns a copy of the note with formatted text. `Text` is set to its plain version for
+// consumers that don't understand segments.
+func (n Note) WithSegments(segments ...Segment) Note {
+	n.Segments = segments
+	n.Text = RichText(segments).Plain()
+	return n
+}
+
+// Note's formatted text, plain `Text` when it has no segments.
+func (n *Note) RichText() RichText {
+	if len(n.Segments) > 0 {
+		return n.Segments
+	}
+	return RichText{Plain(n.Text)}
+}
+
+func (t RichText) Plain() string {
+	var text strings.Builder
+	for _, segment := range t {
+		text.WriteString(segment.Text)
+	}
+	return text.String()
+}
+
+func (t RichText) HTML() string {
+	var text strings.Builder
+	for _, segment := range t {
+		escaped := html.EscapeString(segment.Text)
+		switch segment.Kind {
+		case SegmentCode:
+			text.WriteString("<code>" + escaped + "</code>")
+		case SegmentEmphasis:
+			text.WriteString("<b>" + escaped + "</b>")
+		case SegmentPath:
+			text.WriteString(`<code class="path">` + escaped + "</code>")
+		default:
+			text.WriteString(escaped)
+		}
+	}
+	return text.String()
+}
+
+func (t RichText) Markdown() string {
+	var text strings.Builder
+	for _, segment := range t {
+		switch segment.Kind {
+		case SegmentCode, SegmentPath:
+			text.WriteString(markdownCode(segment.Text))
+		case SegmentEmphasis:
+			text.WriteString("**" + markdownEscape(segment.Text) + "**")
+		default:
+			text.WriteString(markdownEscape(segment.Text))
+		}
+	}
+	return text.String()
+}
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+)
+
+// Text for terminals, formatted with ANSI escape codes.
+func (t RichText) ANSI() string {
+	var text strings.Builder
+	for _, segment := range t {
+		switch segment.Kind {
+		case SegmentCode:
+			text.WriteString(ansiCyan + segment.Text + ansiReset)
+		case SegmentEmphasis:
+			text.WriteString(ansiBold + segment.Text + ansiReset)
+		case SegmentPath:
+			text.WriteString(ansiUnderline + segment.Text + ansiReset)
+		default:
+			text.WriteString(segment.Text)
+		}
+	}
+	return text.String()
+}
+
+// Inline code span that survives backticks inside the text.
+func markdownCode(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
@@ -0,0 +1,54 @@
+package lib
+
+import "slices"
+
+var Variants = []Variant{Success, Warning, Error, Info}
+
+// Whether the whole fixer is disabled for the operation.
+func (c *AppConfig) FixerDisabled(operation Operation, fixer string) bool {
+	return slices.Contains(c.Disabled[operation], fixer)
+}
+
+// Whether the fixer's rule is disabled for the operation.
+func (c *AppConfig) RuleDisabled(operation Operation, fixer string, rule string) bool {
+	disabled := c.Disabled[operation]
+	return rule != "" && (slices.Contains(disabled, rule) || slices.Contains(disabled, fixer+"/"+rule))
+}
+
+// Variant the rule should report, fixer specific overrides take precedence.
+func (c *AppConfig) RuleVariant(fixer string, rule string, variant Variant) Variant {
+	if rule == "" {
+		return variant
+	}
+	if override, ok := c.Severity[fixer+"/"+rule]; ok && slices.Contains(Variants, override) {
+		return override
+	}
+	if override, ok := c.Severity[rule]; ok && slices.Contains(Variants, override) {
+		return override
+	}
+	return variant
+}
+
+// Whether the note's code is disabled for the operation.
+func (c *AppConfig) CodeDisabled(operation Operation, code string) bool {
+	return code != "" && slices.Contains(c.Disabled[operation], code)
+}
+
+// Drops notes of disabled rules and codes, and applies variant overrides. Returns false when
+// nothing worth reporting is left in the message.
+func (c *AppConfig) ApplyRules(operation Operation, message *Message) bool {
+	notes := make([]Note, 0, len(message.Notes))
+	for _, note := range message.Notes {
+		if c.RuleDisabled(operation, message.Fixer, note.Rule) || c.CodeDisabled(operation, note.Code) {
+			continue
+		}
+		if override, ok := c.Severity[note.Code]; ok && note.Code != "" && slices.Contains(Variants, override) {
+			note.Variant = override
+		} else {
+			note.Variant = c.RuleVariant(message.Fixer, note.Rule, note.Variant)
+		}
+		notes = append(notes, note)
+	}
+	message.Notes = notes
+	return len(notes) > 0 || message.Modified
+}
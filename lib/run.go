@@ -0,0 +1,370 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Operation string
+
+const (
+	// Initializes Clothing Plugin Manager in .vaj files.
+	OpInit Operation = "init"
+	// Fixes .vaj, .clothingplugins, and .vap files for release.
+	OpFix Operation = "fix"
+	// Fixes gender in hair & clothing .vam files to match the directory they are in.
+	OpGender Operation = "gender"
+	// Checks that files referenced by package files exist, without modifying anything.
+	OpValidate Operation = "validate"
+)
+
+var Operations = []Operation{OpInit, OpFix, OpGender, OpValidate}
+
+func ParseOperation(name string) (Operation, error) {
+	for _, op := range Operations {
+		if string(op) == name {
+			return op, nil
+		}
+	}
+	return "", fmt.Errorf("unknown operation \"%s\"", name)
+}
+
+type RunOptions struct {
+	// Config to run with, defaults are used when nil.
+	Config *AppConfig `json:"-"`
+	// Names of fixers to run, all fixers run when empty.
+	Fixers []string `json:"fixers"`
+	// Directory of incremental state files. Files unchanged since they were last validated without
+	// problems are skipped. Empty disables incremental runs.
+	StateDir string `json:"-"`
+	// Process all files, even unchanged ones. Their state is still updated.
+	Force bool `json:"force"`
+	// Only these files inside paths are processed, when not nil. They are walked from paths, so
+	// ignore patterns apply to them the same way as in a full run.
+	Files []string `json:"-"`
+}
+
+// How often progress updates are reported while an operation is running.
+const progressInterval = 200 * time.Millisecond
+
+// A single walked file (or a walk failure) passed between the walker, workers, and emitter.
+type walkJob struct {
+	index    int
+	path     string
+	messages []*Message
+}
+
+// Runs the operation on all files in paths using a pool of workers. Messages are reported
+// in the order the files were walked (lexical per root), so the output is stable no matter
+// which worker finishes first. Ends by reporting a summary message, which is also returned.
+func Run(ctx context.Context, reporter Reporter, operation Operation, paths []string, options RunOptions) *Summary {
+	config := options.Config
+	if config == nil {
+		config = NewAppConfig()
+	}
+
+	summary := NewSummary(string(operation), paths)
+	projects := NewProjectConfigs(config)
+	var state *StateStore
+	// Validation depends on other files than the validated one, so its results can't be kept
+	if options.StateDir != "" && operation != OpValidate {
+		state = NewStateStore(options.StateDir)
+	}
+
+	var scanned, matched, modified, unchanged atomic.Int64
+	var current atomic.Value
+	current.Store("")
+	progress := func(done bool) *Progress {
+		return &Progress{
+			Operation: string(operation),
+			Scanned:   int(scanned.Load()),
+			Matched:   int(matched.Load()),
+			Modified:  int(modified.Load()),
+			Current:   current.Load().(string),
+			Done:      done,
+		}
+	}
+
+	jobs := make(chan *walkJob)
+	results := make(chan *walkJob)
+
+	var workers sync.WaitGroup
+	for i := 0; i < config.WorkerCount(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				if job.messages == nil && ctx.Err() == nil {
+					current.Store(job.path)
+					var result dispatchResult
+					job.messages, result = options.dispatch(ctx, state, projects.ConfigFor(job.path), operation, job.path)
+					switch result {
+					case dispatchProcessed:
+						matched.Add(1)
+					case dispatchUnchanged:
+						unchanged.Add(1)
+					}
+					for _, message := range job.messages {
+						if message.Modified {
+							modified.Add(1)
+						}
+					}
+				}
+				results <- job
+			}
+		}()
+	}
+
+	// Producer
+	walker := newWalker(ctx, config, projects)
+	if options.Files != nil {
+		walker.limit(options.Files)
+	}
+	go func() {
+		defer close(jobs)
+		index := 0
+		send := func(job *walkJob) error {
+			select {
+			case jobs <- job:
+				index++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		visit := func(filePath string) error {
+			scanned.Add(1)
+			return send(&walkJob{index: index, path: filePath})
+		}
+		onError := func(filePath string, err error) {
+			send(&walkJob{index: index, messages: []*Message{walkErrorMessage(filePath, err)}})
+		}
+
+		for _, path := range slices.Sorted(slices.Values(paths)) {
+			if walker.walk(path, visit, onError) != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	// Emitter, buffers out of order results until all previous ones arrive
+	pending := map[int]*walkJob{}
+	next := 0
+	for results != nil {
+		select {
+		case job, ok := <-results:
+			if !ok {
+				results = nil
+				break
+			}
+			pending[job.index] = job
+			for {
+				job, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				for _, message := range job.messages {
+					summary.Add(message)
+					reporter.Message(message)
+				}
+				next++
+			}
+		case <-ticker.C:
+			reporter.Progress(progress(false))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		reporter.Message(&Message{Title: "Operation cancelled", Notes: []Note{{
+			Variant: "warning",
+			Rule:    "operation-cancelled",
+			Code:    "CPU006",
+			Text:    fmt.Sprintf("Operation was cancelled after scanning %d files.", scanned.Load()),
+			Details: Ptr(err.Error()),
+		}}})
+	}
+
+	if state != nil {
+		if err := state.Save(); err != nil {
+			message := &Message{Icon: Ptr("file"), Title: options.StateDir, Notes: []Note{{
+				Variant: Warning,
+				Rule:    "state-save-failed",
+				Code:    "CPU004",
+				Text:    "Couldn't save incremental state, unchanged files will be processed again next time.",
+				Details: Ptr(err.Error()),
+			}}}
+			summary.Add(message)
+			reporter.Message(message)
+		}
+	}
+
+	// Safe to read, walker is done once results are closed
+	summary.Pruned = append(summary.Pruned, walker.pruned...)
+	summary.Ignored = walker.ignored
+	summary.Configs = projects.Applied()
+	summary.Unchanged = int(unchanged.Load())
+	summary.Finish(int(scanned.Load()), int(matched.Load()))
+	reporter.Message(summary.Message())
+	reporter.Progress(progress(true))
+
+	return summary
+}
+
+func walkErrorMessage(filePath string, err error) *Message {
+	if errors.Is(err, ErrProjectConfig) {
+		return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+			Variant: "danger",
+			Rule:    "project-config-invalid",
+			Code:    "CPU003",
+			Text:    "Couldn't load project config, using app config for this package.",
+			Details: Ptr(err.Error()),
+		}}}
+	}
+	if errors.Is(err, ErrInvalidIgnorePattern) {
+		return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+			Variant: "warning",
+			Rule:    "ignore-pattern-invalid",
+			Code:    "CPU018",
+			Text:    "Skipped an invalid pattern, the rest of the ignore file applies.",
+			Details: Ptr(err.Error()),
+		}}}
+	}
+	if errors.Is(err, ErrAlreadyWalked) {
+		return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+			Variant: "warning",
+			Rule:    "already-walked",
+			Code:    "CPU002",
+			Text:    "Directory was already walked, skipping.",
+			Details: Ptr(err.Error()),
+		}}}
+	}
+	return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{Note{
+		Variant: "danger",
+		Rule:    "walk-failed",
+		Code:    "CPU001",
+		Details: Ptr(err.Error()),
+	}.WithSegments(Plain("Failed to walk path "), PathSegment(filePath), Plain("."))}}
+}
+
+type dispatchResult int
+
+const (
+	// No enabled fixer handles the file.
+	dispatchSkipped dispatchResult = iota
+	dispatchProcessed
+	// File didn't change since it was last validated without problems.
+	dispatchUnchanged
+)
+
+// Runs all enabled fixers relevant to the operation on a file. When state is passed, unchanged
+// files are skipped, and files that end up without problems are recorded.
+func (o *RunOptions) dispatch(ctx context.Context, state *StateStore, config *AppConfig, operation Operation, path string) ([]*Message, dispatchResult) {
+	file := &File{Path: path, Operation: operation, Config: config}
+
+	var fixers []Fixer
+	for _, fixer := range registry {
+		name := fixer.Name()
+		if !slices.Contains(fixer.Modes(), operation) || !o.fixerEnabled(name) || config.FixerDisabled(operation, name) {
+			continue
+		}
+		if fixer.Match(path) {
+			fixers = append(fixers, fixer)
+		}
+	}
+	if len(fixers) == 0 {
+		return nil, dispatchSkipped
+	}
+
+	var fileState *FileState
+	var dependencies []string
+	if state != nil {
+		fileState = &FileState{Operation: operation, Config: hashConfig(config), Fixers: map[string]int{}}
+		for _, fixer := range fixers {
+			fileState.Fixers[fixer.Name()] = fixer.Version()
+			if dependent, ok := fixer.(DependentFixer); ok {
+				paths, ok := dependent.Dependencies(file)
+				if !ok {
+					// Results can't be reused, the file is processed every time
+					fileState = nil
+					break
+				}
+				dependencies = append(dependencies, paths...)
+			}
+		}
+	}
+	if fileState != nil {
+		fileState.Hash, _ = HashFile(path)
+		fileState.Dependencies = hashDependencies(dependencies)
+		if !o.Force && fileState.Hash != "" && state.Unchanged(path, fileState) {
+			return nil, dispatchUnchanged
+		}
+	}
+
+	var messages []*Message
+	clean := true
+	for _, fixer := range fixers {
+		message := fixer.Fix(ctx, file)
+		message.Fixer = fixer.Name()
+		for i := range message.Notes {
+			message.Notes[i].Fixer = fixer.Name()
+		}
+		if config.ApplyRules(operation, message) {
+			messages = append(messages, message)
+		}
+		for _, note := range message.Notes {
+			if note.Variant == Error || note.Variant == Warning {
+				clean = false
+			}
+		}
+	}
+
+	if state != nil {
+		hash, err := HashFile(path)
+		if fileState != nil && clean && err == nil && ctx.Err() == nil {
+			fileState.Hash = hash
+			fileState.Dependencies = hashDependencies(dependencies)
+			state.Record(path, fileState)
+		} else {
+			state.Forget(path)
+		}
+	}
+
+	return messages, dispatchProcessed
+}
+
+func (o *RunOptions) fixerEnabled(name string) bool {
+	return len(o.Fixers) == 0 || slices.Contains(o.Fixers, name)
+}
+
+// Checks that all fixers enabled in options exist.
+func (o *RunOptions) Validate() error {
+	for _, name := range o.Fixers {
+		if _, ok := GetFixer(name); !ok {
+			return fmt.Errorf("unknown fixer \"%s\"", name)
+		}
+	}
+	return nil
+}
+
+// Number of files processed in parallel, defaults to the number of CPUs.
+func (c *AppConfig) WorkerCount() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return runtime.NumCPU()
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Creates files with contents under root, which is returned normalized to forward slashes.
+func writeFiles(t *testing.T, root string, files map[string]string) string {
+	t.Helper()
+	root = filepath.ToSlash(root)
+	for name, data := range files {
+		filePath := path.Join(root, name)
+		if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Titles of file messages, in the order they were reported.
+func fileTitles(messages []*Message) []string {
+	titles := []string{}
+	for _, message := range messages {
+		if isFileMessage(message) {
+			titles = append(titles, message.Title)
+		}
+	}
+	return titles
+}
+
+func hasCode(messages []*Message, code string) bool {
+	return slices.ContainsFunc(messages, func(message *Message) bool {
+		return slices.ContainsFunc(message.Notes, func(note Note) bool { return note.Code == code })
+	})
+}
+
+const maleItem = `{"itemType":"ClothingMale"}`
+
+func TestRunReportsInWalkOrder(t *testing.T) {
+	files := map[string]string{}
+	var want []string
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("Custom/Clothing/Female/Author/Item/Item%02d.vam", i)
+		files[name] = maleItem
+		want = append(want, name)
+	}
+	// Not handled by any fixer, scanned but skipped
+	files["Custom/Clothing/Female/Author/Item/readme.txt"] = "text"
+	root := writeFiles(t, t.TempDir(), files)
+	for i := range want {
+		want[i] = path.Join(root, want[i])
+	}
+
+	config := NewAppConfig()
+	config.Workers = 8
+	memory := NewMemoryReporter()
+	summary := Run(context.Background(), memory, OpGender, []string{root}, RunOptions{Config: config})
+
+	if got := fileTitles(memory.Messages()); !slices.Equal(got, want) {
+		t.Errorf("messages out of walk order:\n%v\nwant:\n%v", got, want)
+	}
+	if summary.Scanned != 41 || summary.Processed != 40 || summary.Modified != 40 || summary.Skipped != 1 {
+		t.Errorf("summary scanned %d, processed %d, modified %d, skipped %d, want 41, 40, 40, 1",
+			summary.Scanned, summary.Processed, summary.Modified, summary.Skipped)
+	}
+	if summary.Variants[Success] != 80 {
+		t.Errorf("summary counted %d success notes, want 80", summary.Variants[Success])
+	}
+
+	messages := memory.Messages()
+	if last := messages[len(messages)-1]; last.Summary != summary {
+		t.Errorf("last message isn't the summary: %+v", last)
+	}
+	if progress := memory.LastProgress(); progress == nil || !progress.Done || progress.Modified != 40 {
+		t.Errorf("last progress %+v, want done with 40 modified", progress)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"Custom/Clothing/Female/Author/Item/Item.vam": maleItem,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	memory := NewMemoryReporter()
+	summary := Run(ctx, memory, OpGender, []string{root}, RunOptions{})
+
+	if !hasCode(memory.Messages(), "CPU006") {
+		t.Error("cancelled run didn't report CPU006")
+	}
+	if summary.Processed != 0 || summary.Modified != 0 {
+		t.Errorf("cancelled run processed %d and modified %d files", summary.Processed, summary.Modified)
+	}
+	data, _ := os.ReadFile(path.Join(root, "Custom/Clothing/Female/Author/Item/Item.vam"))
+	if string(data) != maleItem {
+		t.Errorf("cancelled run modified file: %s", data)
+	}
+}
+
+func TestRunFilesApplyIgnorePatterns(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"AddonPackagesBuilder/A.B.1.var/.cpuignore":                           "WIP/\n",
+		"AddonPackagesBuilder/A.B.1.var/Custom/Clothing/Female/A/I/I.vam":     maleItem,
+		"AddonPackagesBuilder/A.B.1.var/Custom/Clothing/Female/A/I/J.vam":     maleItem,
+		"AddonPackagesBuilder/A.B.1.var/WIP/Custom/Clothing/Female/A/I.vam":   maleItem,
+		"AddonPackagesBuilder/A.B.1.var/Other/Custom/Clothing/Female/A/I.vam": maleItem,
+	})
+	pkg := path.Join(root, "AddonPackagesBuilder/A.B.1.var")
+	config := NewAppConfig()
+	config.Include = []string{"Custom/**"}
+	options := RunOptions{Config: config, Files: []string{
+		path.Join(pkg, "Custom/Clothing/Female/A/I/I.vam"),
+		path.Join(pkg, "WIP/Custom/Clothing/Female/A/I.vam"),
+		path.Join(pkg, "Other/Custom/Clothing/Female/A/I.vam"),
+	}}
+
+	memory := NewMemoryReporter()
+	summary := Run(context.Background(), memory, OpGender, []string{pkg}, options)
+
+	want := []string{path.Join(pkg, "Custom/Clothing/Female/A/I/I.vam")}
+	if got := fileTitles(memory.Messages()); !slices.Equal(got, want) {
+		t.Errorf("processed %v, want %v", got, want)
+	}
+	if summary.Scanned != 1 || summary.Ignored != 1 {
+		t.Errorf("scanned %d and ignored %d files, want 1 and 1", summary.Scanned, summary.Ignored)
+	}
+	if !slices.Equal(summary.Pruned, []string{path.Join(pkg, "WIP")}) {
+		t.Errorf("pruned %v, want WIP", summary.Pruned)
+	}
+}
+
+func TestRunSkipsUnchangedFiles(t *testing.T) {
+	item := "AddonPackagesBuilder/Me.Pkg.1.var/Custom/Clothing/Female/A/I/"
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		item + "I.vam": `{"itemType":"ClothingFemale","uid":"A:I"}`,
+		item + "I.vaj": `{"components":[],"storables":[]}`,
+	})
+	options := RunOptions{Config: NewAppConfig(), StateDir: t.TempDir()}
+	run := func() *Summary {
+		return Run(context.Background(), NewMemoryReporter(), OpInit, []string{root}, options)
+	}
+
+	if summary := run(); summary.Modified != 1 {
+		t.Fatalf("first run modified %d files, want 1", summary.Modified)
+	}
+	if summary := run(); summary.Unchanged != 1 || summary.Processed != 0 {
+		t.Errorf("second run processed %d files with %d unchanged, want 0 and 1", summary.Processed, summary.Unchanged)
+	}
+
+	// Settings that don't affect results keep the state
+	options.Config.Workers = 3
+	options.Config.OnTop = true
+	if summary := run(); summary.Unchanged != 1 {
+		t.Errorf("changing workers invalidated the state")
+	}
+
+	// The .vaj depends on the UID in the .vam
+	writeFiles(t, root, map[string]string{item + "I.vam": `{"itemType":"ClothingFemale","uid":"A:Other"}`})
+	if summary := run(); summary.Unchanged != 0 || summary.Modified != 1 {
+		t.Errorf("run after UID change modified %d files with %d unchanged, want 1 and 0", summary.Modified, summary.Unchanged)
+	}
+}
+
+func TestRunEmptyFixersRunsAll(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"Custom/Clothing/Female/Author/Item/Item.vam": maleItem,
+	})
+	summary := Run(context.Background(), NewMemoryReporter(), OpGender, []string{root}, RunOptions{Fixers: []string{}})
+	if summary.Modified != 1 {
+		t.Errorf("run with no fixers listed modified %d files, want 1", summary.Modified)
+	}
+}
@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Minimal subset of the SARIF 2.1.0 object model.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations,omitempty"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	StartTimeUTC        string `json:"startTimeUtc,omitempty"`
+	EndTimeUTC          string `json:"endTimeUtc,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Kind      string          `json:"kind"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIF requires level "none" for every kind other than "fail".
+var variantSarifLevels = map[Variant]string{
+	Error:   "error",
+	Warning: "warning",
+	Info:    "none",
+	Success: "none",
+}
+
+var variantSarifKinds = map[Variant]string{
+	Error:   "fail",
+	Warning: "fail",
+	Info:    "informational",
+	Success: "pass",
+}
+
+// Maps every note of the report to a SARIF result. Summaries become invocations.
+func (r *Report) sarif() *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "Clothing Plugins Util",
+			InformationURI: "https://github.com/qdaro/vam-clothing-plugins-util",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	var ruleIDs []string
+	for _, message := range r.Messages {
+		if message.Summary != nil {
+			summary := message.Summary
+			run.Invocations = append(run.Invocations, sarifInvocation{
+				ExecutionSuccessful: summary.Variants[Error] == 0,
+				StartTimeUTC:        summary.Started.UTC().Format(time.RFC3339),
+				EndTimeUTC:          summary.Finished.UTC().Format(time.RFC3339),
+			})
+			continue
+		}
+
+		for i := range message.Notes {
+			note := &message.Notes[i]
+			id := noteRuleID(message, note)
+			index := slices.Index(ruleIDs, id)
+			if index < 0 {
+				index = len(ruleIDs)
+				ruleIDs = append(ruleIDs, id)
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, Name: note.Rule})
+			}
+
+			result := sarifResult{
+				RuleID:    id,
+				RuleIndex: index,
+				Kind:      variantSarifKinds[note.Variant],
+				Level:     variantSarifLevels[note.Variant],
+				Message:   sarifMessage{Text: note.Text, Markdown: note.RichText().Markdown()},
+			}
+			if note.Details != nil {
+				details := strings.TrimRight(*note.Details, "\n")
+				result.Message.Text += "\n\n" + details
+				result.Message.Markdown += "\n\n```\n" + details + "\n```"
+			}
+			if isFileMessage(message) {
+				result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: fileURI(message.Title)},
+				}}}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// Absolute `file://` URI of the path.
+func fileURI(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = abs
+	}
+	filePath = filepath.ToSlash(filePath)
+	if !strings.HasPrefix(filePath, "/") {
+		// Windows drive letter paths
+		filePath = "/" + filePath
+	}
+	return (&url.URL{Scheme: "file", Path: filePath}).String()
+}
@@ -0,0 +1,202 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Version of the state file format, files with a different one are discarded.
+const stateFileVersion = 1
+
+// Last successful validation of a file.
+type FileState struct {
+	// Content hash of the file after it was validated.
+	Hash      string    `json:"hash"`
+	Operation Operation `json:"operation"`
+	// Hash of the config the file was validated with.
+	Config string `json:"config"`
+	// Versions of fixers that validated the file, by name.
+	Fixers map[string]int `json:"fixers"`
+	// Content hashes of other files the fixers read, by path. Empty when the file doesn't exist.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+type packageState struct {
+	Version int    `json:"version"`
+	Root    string `json:"root"`
+	// File states by path relative to package root.
+	Files map[string]*FileState `json:"files"`
+}
+
+// Remembers files that were validated without problems so unchanged ones can be skipped on next
+// runs. Keeps a state file per package in dir. Safe for concurrent use.
+type StateStore struct {
+	mu       sync.Mutex
+	dir      string
+	packages map[string]*packageState
+	dirty    map[string]bool
+}
+
+func NewStateStore(dir string) *StateStore {
+	return &StateStore{dir: dir, packages: map[string]*packageState{}, dirty: map[string]bool{}}
+}
+
+// Whether the file's current state matches the last successful validation.
+func (s *StateStore) Unchanged(filePath string, state *FileState) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg, rel, ok := s.get(filePath)
+	if !ok {
+		return false
+	}
+	last, ok := pkg.Files[rel]
+	return ok &&
+		last.Hash == state.Hash &&
+		last.Operation == state.Operation &&
+		last.Config == state.Config &&
+		maps.Equal(last.Fixers, state.Fixers) &&
+		maps.Equal(last.Dependencies, state.Dependencies)
+}
+
+// Records a successful validation of the file. Files outside of packages are ignored.
+func (s *StateStore) Record(filePath string, state *FileState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pkg, rel, ok := s.get(filePath); ok {
+		pkg.Files[rel] = state
+		s.dirty[pkg.Root] = true
+	}
+}
+
+// Removes file's state, so it's processed again on the next run.
+func (s *StateStore) Forget(filePath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pkg, rel, ok := s.get(filePath); ok {
+		if _, exists := pkg.Files[rel]; exists {
+			delete(pkg.Files, rel)
+			s.dirty[pkg.Root] = true
+		}
+	}
+}
+
+// Writes states of packages that changed.
+func (s *StateStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.dirty) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	var errs []error
+	for root := range s.dirty {
+		data, err := json.Marshal(s.packages[root])
+		if err == nil {
+			err = os.WriteFile(s.statePath(root), data, 0644)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(s.dirty, root)
+	}
+	return errors.Join(errs...)
+}
+
+// Returns state of the package the file is in, loading it on first access. Requires lock.
+func (s *StateStore) get(filePath string) (pkg *packageState, rel string, ok bool) {
+	root, ok := GetPackageRoot(filePath)
+	if !ok {
+		return nil, "", false
+	}
+	rel, ok = relativeTo(root, filePath)
+	if !ok {
+		return nil, "", false
+	}
+	rel = strings.ToLower(rel)
+
+	pkg, ok = s.packages[root]
+	if !ok {
+		pkg = s.load(root)
+		s.packages[root] = pkg
+	}
+	return pkg, rel, true
+}
+
+// Reads the package's state file. Missing, corrupt, or outdated files result in an empty state.
+func (s *StateStore) load(root string) *packageState {
+	empty := &packageState{Version: stateFileVersion, Root: root, Files: map[string]*FileState{}}
+
+	data, err := os.ReadFile(s.statePath(root))
+	if err != nil {
+		return empty
+	}
+	pkg := &packageState{}
+	if json.Unmarshal(data, pkg) != nil || pkg.Version != stateFileVersion || !strings.EqualFold(pkg.Root, root) || pkg.Files == nil {
+		return empty
+	}
+	return pkg
+}
+
+func (s *StateStore) statePath(root string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(root)))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// SHA-256 of the file's contents.
+func HashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Hashes of the files, missing ones have an empty hash.
+func hashDependencies(paths []string) map[string]string {
+	if len(paths) == 0 {
+		return nil
+	}
+	hashes := make(map[string]string, len(paths))
+	for _, dependency := range paths {
+		hashes[dependency], _ = HashFile(dependency)
+	}
+	return hashes
+}
+
+// SHA-256 of the settings that affect results of fixers, so changing others (e.g. workers) doesn't
+// invalidate the state.
+func hashConfig(config *AppConfig) string {
+	data, err := json.Marshal(struct {
+		Disabled         map[Operation][]string `json:"disabled"`
+		Severity         map[string]Variant     `json:"severity"`
+		Include          []string               `json:"include"`
+		Exclude          []string               `json:"exclude"`
+		NamespaceVersion string                 `json:"namespaceVersion"`
+	}{config.Disabled, config.Severity, config.Include, config.Exclude, config.NamespaceVersion})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
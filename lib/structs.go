@@ -11,16 +11,70 @@ const (
 
 type Note struct {
 	Variant Variant `json:"variant"`
-	Text    string  `json:"text"`
-	Details *string `json:"details,omitempty"`
+	// Identifies the check that produced the note, used to disable it or override its variant.
+	Rule string `json:"rule,omitempty"`
+	// Stable machine-readable code of the finding, e.g. `VAJ001`. Never reused for a different check.
+	Code string `json:"code,omitempty"`
+	// Name of the fixer that produced the note.
+	Fixer string `json:"fixer,omitempty"`
+	// JSON path (gjson syntax) of the property the note concerns. Empty when it's about the whole file.
+	Path string `json:"path,omitempty"`
+	// Plain text, see `Segments` for the formatted version.
+	Text string `json:"text"`
+	// Formatted text, set with `WithSegments`. Empty when the note has no formatting.
+	Segments RichText `json:"segments,omitempty"`
+	Details  *string  `json:"details,omitempty"`
 }
 
 type Message struct {
-	Icon  *string `json:"icon,omitempty"`
-	Title string  `json:"title"`
-	Notes []Note  `json:"notes"`
+	Icon     *string `json:"icon,omitempty"`
+	Title    string  `json:"title"`
+	Notes    []Note  `json:"notes"`
+	Modified bool    `json:"modified,omitempty"`
+	// Name of the fixer that produced the message.
+	Fixer string `json:"fixer,omitempty"`
+	// Set on the message emitted at the end of an operation.
+	Summary *Summary `json:"summary,omitempty"`
+}
+
+// Periodically emitted while an operation is running.
+type Progress struct {
+	Operation string `json:"operation"`
+	Scanned   int    `json:"scanned"`
+	Matched   int    `json:"matched"`
+	Modified  int    `json:"modified"`
+	Current   string `json:"current"`
+	Done      bool   `json:"done"`
 }
 
 type AppConfig struct {
-	OnTop bool `json:"onTop"`
+	// Schema version, see `AppConfigVersion`.
+	Version int  `json:"version"`
+	OnTop   bool `json:"onTop"`
+	// Number of files processed in parallel. 0 = number of CPUs.
+	Workers int `json:"workers"`
+	// Fixers and rules disabled per operation. Entries are fixer names (`vap`), rules
+	// (`not-prepped`), rules of a specific fixer (`cpl/not-prepped`), or note codes (`CPL001`).
+	Disabled map[Operation][]string `json:"disabled,omitempty"`
+	// Overrides the variant reported by rules. Keys are rules (`not-prepped`), rules of
+	// a specific fixer (`cpl/not-prepped`), or note codes (`CPL001`), which take precedence.
+	Severity map[string]Variant `json:"severity,omitempty"`
+	// Gitignore syntax patterns relative to walked paths. When not empty, only matching files are processed.
+	Include []string `json:"include"`
+	// Gitignore syntax patterns relative to walked paths that are skipped, in addition to .cpuignore files.
+	Exclude []string `json:"exclude"`
+	// Walk into symlinked directories. Loops are detected and skipped.
+	FollowSymlinks bool `json:"followSymlinks"`
+	// Version used when namespacing paths to the package itself: latest (default), exact, or min.
+	NamespaceVersion string `json:"namespaceVersion,omitempty"`
+	// Name of the profile this config belongs to. Empty is the default profile.
+	Profile string `json:"profile,omitempty"`
+}
+
+// Config with default values, used when nothing was loaded yet.
+func NewAppConfig() *AppConfig {
+	return &AppConfig{
+		Version: AppConfigVersion,
+		Exclude: []string{".git/"},
+	}
 }
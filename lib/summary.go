@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Overall result of an operation, accumulated from all messages it emitted.
+type Summary struct {
+	Operation string    `json:"operation"`
+	Roots     []string  `json:"roots"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Scanned   int       `json:"scanned"`
+	Processed int       `json:"processed"`
+	Modified  int       `json:"modified"`
+	Skipped   int       `json:"skipped"`
+	// Files skipped by incremental runs because they didn't change since they were last validated.
+	Unchanged int `json:"unchanged"`
+	// Files skipped by ignore files or include/exclude patterns.
+	Ignored int `json:"ignored"`
+	// Directories skipped by ignore files or exclude patterns.
+	Pruned []string `json:"pruned"`
+	// Project config files that were merged over app config.
+	Configs  []string        `json:"configs"`
+	Variants map[Variant]int `json:"variants"`
+	Fixers   map[string]int  `json:"fixers"`
+	// Files that produced errors or warnings.
+	Problems []string `json:"problems"`
+}
+
+func NewSummary(operation string, roots []string) *Summary {
+	return &Summary{
+		Operation: operation,
+		Roots:     roots,
+		Started:   time.Now(),
+		Variants:  map[Variant]int{},
+		Fixers:    map[string]int{},
+		Problems:  []string{},
+		Pruned:    []string{},
+		Configs:   []string{},
+	}
+}
+
+// Counts message's notes towards the summary.
+func (s *Summary) Add(message *Message) {
+	hasProblem := false
+	for _, note := range message.Notes {
+		s.Variants[note.Variant]++
+		if note.Variant == Error || note.Variant == Warning {
+			hasProblem = true
+		}
+	}
+	if message.Fixer != "" {
+		s.Fixers[message.Fixer]++
+	}
+	if message.Modified {
+		s.Modified++
+	}
+	if hasProblem && !slices.Contains(s.Problems, message.Title) {
+		s.Problems = append(s.Problems, message.Title)
+	}
+}
+
+// Marks the summary as finished. Scanned files that weren't processed by any fixer, including
+// unchanged ones, are counted as skipped.
+func (s *Summary) Finish(scanned int, processed int) {
+	s.Finished = time.Now()
+	s.Scanned = scanned
+	s.Processed = processed
+	s.Skipped = scanned - processed
+}
+
+// Adds counts of another summary, e.g. of a job step, to this one.
+func (s *Summary) Merge(other *Summary) {
+	s.Scanned += other.Scanned
+	s.Processed += other.Processed
+	s.Modified += other.Modified
+	s.Skipped += other.Skipped
+	s.Unchanged += other.Unchanged
+	s.Ignored += other.Ignored
+	s.Pruned = append(s.Pruned, other.Pruned...)
+	for _, config := range other.Configs {
+		if !slices.Contains(s.Configs, config) {
+			s.Configs = append(s.Configs, config)
+		}
+	}
+	for variant, count := range other.Variants {
+		s.Variants[variant] += count
+	}
+	for fixer, count := range other.Fixers {
+		s.Fixers[fixer] += count
+	}
+	for _, problem := range other.Problems {
+		if !slices.Contains(s.Problems, problem) {
+			s.Problems = append(s.Problems, problem)
+		}
+	}
+}
+
+func (s *Summary) Duration() time.Duration {
+	return s.Finished.Sub(s.Started)
+}
+
+// Formats the summary into a message to be displayed at the end of the operation output.
+func (s *Summary) Message() *Message {
+	notes := []Note{{
+		Variant: Info,
+		Text: fmt.Sprintf(
+			"Scanned %d files, processed %d, modified %d, skipped %d in %s.",
+			s.Scanned, s.Processed, s.Modified, s.Skipped, s.Duration().Round(time.Millisecond),
+		),
+	}}
+
+	if s.Unchanged > 0 {
+		notes = append(notes, Note{
+			Variant: Info,
+			Text:    fmt.Sprintf("Skipped %d files that didn't change since they were last validated. Force the run to process them anyway.", s.Unchanged),
+		})
+	}
+
+	if len(s.Configs) > 0 {
+		notes = append(notes, Note{
+			Variant: Info,
+			Text:    fmt.Sprintf("Applied %d project configs.", len(s.Configs)),
+			Details: Ptr(strings.Join(s.Configs, "\n")),
+		})
+	}
+
+	if s.Ignored > 0 || len(s.Pruned) > 0 {
+		note := Note{
+			Variant: Info,
+			Text:    fmt.Sprintf("Ignored %d files and %d directories.", s.Ignored, len(s.Pruned)),
+		}
+		if len(s.Pruned) > 0 {
+			note.Details = Ptr(strings.Join(s.Pruned, "\n"))
+		}
+		notes = append(notes, note)
+	}
+
+	if len(s.Variants) > 0 {
+		var counts []string
+		for _, variant := range []Variant{Error, Warning, Success, Info} {
+			if count := s.Variants[variant]; count > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", count, variant))
+			}
+		}
+		notes = append(notes, Note{Variant: Info, Text: "Notes: " + strings.Join(counts, ", ") + "."})
+	}
+
+	if len(s.Fixers) > 0 {
+		names := make([]string, 0, len(s.Fixers))
+		for name := range s.Fixers {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		var counts []string
+		for _, name := range names {
+			counts = append(counts, fmt.Sprintf("%s %d", name, s.Fixers[name]))
+		}
+		notes = append(notes, Note{Variant: Info, Text: "Fixers: " + strings.Join(counts, ", ") + "."})
+	}
+
+	if len(s.Problems) > 0 {
+		variant := Warning
+		if s.Variants[Error] > 0 {
+			variant = Error
+		}
+		notes = append(notes, Note{
+			Variant: variant,
+			Text:    fmt.Sprintf("%d files produced errors or warnings.", len(s.Problems)),
+			Details: Ptr(strings.Join(s.Problems, "\n")),
+		})
+	} else {
+		notes = append(notes, Note{Variant: Success, Text: "No errors or warnings."})
+	}
+
+	return &Message{Icon: Ptr("summary"), Title: "Summary (" + s.Operation + ")", Notes: notes, Summary: s}
+}
@@ -63,6 +63,15 @@ func JSONMarshalPretty(t any) ([]byte, error) {
 	return buffer.Bytes(), err
 }
 
+// Single line JSON without HTML escaping.
+func JSONMarshalCompact(t any) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(t)
+	return bytes.TrimRight(buffer.Bytes(), "\n"), err
+}
+
 func JSONMarshalLog(t any) string {
 	buffer := &bytes.Buffer{}
 	encoder := json.NewEncoder(buffer)
@@ -0,0 +1,248 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Directory reached again through a symlink (loop or duplicate link) or overlapping dropped paths.
+var ErrAlreadyWalked = errors.New("directory was already walked")
+
+// Project config file that couldn't be loaded, app config is used instead.
+var ErrProjectConfig = errors.New("couldn't load project config")
+
+// Walks files of dropped paths while skipping ignored ones.
+type walker struct {
+	ctx            context.Context
+	include        IgnoreList
+	exclude        IgnoreList
+	followSymlinks bool
+	projects       *ProjectConfigs
+	// Project configs that failed to load and were already reported.
+	reported map[string]bool
+	// Ignore patterns from .cpuignore files, by directory they apply to.
+	ignores map[string]IgnoreList
+	// Real paths of walked directories, used to detect symlink loops.
+	visited map[string]bool
+	// Directories skipped along with everything inside them.
+	pruned []string
+	// Number of ignored files.
+	ignored int
+	// Lowercased paths of the only files to visit, and of directories containing them. Other
+	// entries are skipped without counting them as ignored. All files are visited when nil.
+	only     map[string]bool
+	onlyDirs map[string]bool
+	// Called with every walked directory before its entries, stops the walk when it returns an error.
+	onDir func(dirPath string) error
+	// Include patterns select files to process. When set, they don't apply and only excluded and
+	// ignored files are skipped, e.g. when packing a package.
+	skipInclude bool
+}
+
+func newWalker(ctx context.Context, config *AppConfig, projects *ProjectConfigs) *walker {
+	return &walker{
+		ctx:            ctx,
+		include:        NewIgnoreList("", config.Include),
+		exclude:        NewIgnoreList("", config.Exclude),
+		followSymlinks: config.FollowSymlinks,
+		projects:       projects,
+		reported:       map[string]bool{},
+		ignores:        map[string]IgnoreList{},
+		visited:        map[string]bool{},
+	}
+}
+
+// Limits the walk to files, normalized to forward slashes. Directories that don't contain any of
+// them aren't walked.
+func (w *walker) limit(files []string) {
+	w.only = map[string]bool{}
+	w.onlyDirs = map[string]bool{}
+	for _, filePath := range files {
+		filePath = strings.ToLower(filepath.ToSlash(filepath.Clean(filePath)))
+		w.only[filePath] = true
+		for dir := path.Dir(filePath); !w.onlyDirs[dir] && dir != path.Dir(dir); dir = path.Dir(dir) {
+			w.onlyDirs[dir] = true
+		}
+	}
+}
+
+// Whether the entry is left out by `limit`.
+func (w *walker) excluded(filePath string, isDir bool) bool {
+	if w.only == nil {
+		return false
+	}
+	if isDir {
+		return !w.onlyDirs[strings.ToLower(filePath)]
+	}
+	return !w.only[strings.ToLower(filePath)]
+}
+
+// Calls visit with every non-ignored file in root, normalized to forward slashes. Entries that
+// can't be read are passed to onError and the walk continues. Only returns an error when the
+// context is cancelled, or visit returns one.
+func (w *walker) walk(root string, visit func(filePath string) error, onError func(filePath string, err error)) error {
+	root = filepath.ToSlash(filepath.Clean(root))
+
+	// Dropped paths are followed even when they are symlinks
+	info, err := os.Stat(root)
+	if err != nil {
+		onError(root, err)
+		return nil
+	}
+	w.checkProject(root, onError)
+	if !info.IsDir() {
+		if w.excluded(root, false) {
+			return nil
+		}
+		return w.visitFile(root, root, visit)
+	}
+	return w.walkDir(root, root, visit, onError)
+}
+
+func (w *walker) walkDir(root string, dirPath string, visit func(filePath string) error, onError func(filePath string, err error)) error {
+	if realPath, err := filepath.EvalSymlinks(dirPath); err == nil {
+		if w.visited[realPath] {
+			onError(dirPath, fmt.Errorf("%w: \"%s\"", ErrAlreadyWalked, realPath))
+			return nil
+		}
+		w.visited[realPath] = true
+	}
+
+	w.checkProject(dirPath, onError)
+	w.readIgnores(dirPath, onError)
+	if w.onDir != nil {
+		if err := w.onDir(dirPath); err != nil {
+			return err
+		}
+	}
+
+	// Sorted by name
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		onError(dirPath, err)
+		// ReadDir returns entries read before the error, so we still process those
+	}
+
+	for _, entry := range entries {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+
+		entryPath := path.Join(dirPath, entry.Name())
+		isDir := entry.IsDir()
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(entryPath)
+			if err != nil {
+				onError(entryPath, err)
+				continue
+			}
+			isDir = info.IsDir()
+			if isDir && !w.followSymlinks {
+				continue
+			}
+		}
+
+		if w.excluded(entryPath, isDir) {
+			continue
+		}
+		if w.isIgnored(root, entryPath, isDir) {
+			if isDir {
+				w.pruned = append(w.pruned, entryPath)
+			} else {
+				w.ignored++
+			}
+			continue
+		}
+
+		if isDir {
+			err = w.walkDir(root, entryPath, visit, onError)
+		} else {
+			err = w.visitFile(root, entryPath, visit)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads the directory's .cpuignore file, its patterns apply along with the ones of parent directories.
+func (w *walker) readIgnores(dirPath string, onError func(filePath string, err error)) {
+	ignores, err := ReadIgnoreFile(path.Join(dirPath, IgnoreFileName))
+	if err != nil {
+		onError(path.Join(dirPath, IgnoreFileName), err)
+	}
+	w.ignores[dirPath] = append(slices.Clip(w.ignores[path.Dir(dirPath)]), ignores...)
+}
+
+// Reads .cpuignore files of directories from root down to the directory inside it, as if it was
+// reached by walking root. Returns whether the directory, or a directory above it, is ignored.
+func (w *walker) descend(root string, dirPath string, onError func(filePath string, err error)) bool {
+	root = filepath.ToSlash(filepath.Clean(root))
+	dirPath = filepath.ToSlash(filepath.Clean(dirPath))
+	rel, ok := relativeTo(root, dirPath)
+	if !ok {
+		return false
+	}
+	current := root
+	w.readIgnores(current, onError)
+	for _, name := range strings.Split(rel, "/") {
+		current = path.Join(current, name)
+		if w.isIgnored(root, current, true) {
+			return true
+		}
+		w.readIgnores(current, onError)
+	}
+	return false
+}
+
+func (w *walker) visitFile(root string, filePath string, visit func(filePath string) error) error {
+	if w.skipInclude {
+		return visit(filePath)
+	}
+	if len(w.include) > 0 && !w.include.Ignored(w.relative(root, filePath), false) {
+		w.ignored++
+		return nil
+	}
+	if project := w.projects.Get(filePath); project != nil && len(project.Include) > 0 && !project.Include.Ignored(filePath, false) {
+		w.ignored++
+		return nil
+	}
+	return visit(filePath)
+}
+
+// Reports project config of the package the path is in if it failed to load.
+func (w *walker) checkProject(filePath string, onError func(filePath string, err error)) {
+	project := w.projects.Get(filePath)
+	if project != nil && project.Err != nil && !w.reported[project.Path] {
+		w.reported[project.Path] = true
+		onError(project.Path, fmt.Errorf("%w: %w", ErrProjectConfig, project.Err))
+	}
+}
+
+func (w *walker) isIgnored(root string, filePath string, isDir bool) bool {
+	if w.exclude.Ignored(w.relative(root, filePath), isDir) {
+		return true
+	}
+	if project := w.projects.Get(filePath); project != nil && project.Exclude.Ignored(filePath, isDir) {
+		return true
+	}
+	return w.ignores[path.Dir(filePath)].Ignored(filePath, isDir)
+}
+
+// Path relative to the walked root. When root is a file, that's its name.
+func (w *walker) relative(root string, filePath string) string {
+	if rel, ok := relativeTo(root, filePath); ok {
+		return rel
+	}
+	return path.Base(filePath)
+}
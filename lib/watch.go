@@ -0,0 +1,177 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long files have to stay unchanged before they are processed. VaM writes presets in several steps.
+const watchDebounce = 500 * time.Millisecond
+
+var ErrNotPackage = errors.New("not a package directory in AddonPackagesBuilder")
+
+// Watches a package directory in AddonPackagesBuilder and runs the operation on every changed
+// file one of its enabled fixers handles, until the context is done. Files the operation itself
+// modified are skipped so fixes don't trigger themselves. Config is resolved for every batch of
+// changes, so config changes apply to running watches, and replaces options.Config. Returns once
+// watching has started.
+func Watch(ctx context.Context, reporter Reporter, root string, operation Operation, config func() *AppConfig, options RunOptions) error {
+	root = filepath.ToSlash(filepath.Clean(root))
+	if packageRoot, ok := GetPackageRoot(root); !ok || !strings.EqualFold(packageRoot, root) {
+		return fmt.Errorf("%w: \"%s\"", ErrNotPackage, root)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: \"%s\"", ErrNotPackage, root)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watchDirs(ctx, watcher, config(), root, root); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go watchLoop(ctx, watcher, reporter, root, operation, config, options)
+	return nil
+}
+
+func watchLoop(ctx context.Context, watcher *fsnotify.Watcher, reporter Reporter, root string, operation Operation, config func() *AppConfig, options RunOptions) {
+	defer watcher.Close()
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	// Changed files waiting for the debounce
+	pending := map[string]bool{}
+	// Modification times of files written by the operation, until their change is seen
+	written := map[string]time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			filePath := filepath.ToSlash(event.Name)
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+					delete(written, filePath)
+				}
+				continue
+			}
+			info, err := os.Stat(filePath)
+			if err != nil {
+				delete(written, filePath)
+				continue
+			}
+			if info.IsDir() {
+				if err := watchDirs(ctx, watcher, config(), root, filePath); err != nil {
+					reporter.Message(watchErrorMessage(filePath, err))
+				}
+				continue
+			}
+			if modTime, ok := written[filePath]; ok {
+				delete(written, filePath)
+				if info.ModTime().Equal(modTime) {
+					continue
+				}
+			}
+			if !watchMatches(filePath, operation, config(), options) {
+				continue
+			}
+			pending[filePath] = true
+			timer.Reset(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			reporter.Message(watchErrorMessage(root, err))
+
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for filePath := range pending {
+				paths = append(paths, filePath)
+			}
+			slices.Sort(paths)
+			pending = map[string]bool{}
+
+			// Changed files are walked from the package root, so ignore patterns apply like in a full run
+			runOptions := options
+			runOptions.Config = config()
+			runOptions.Files = paths
+			memory := NewMemoryReporter()
+			Run(ctx, MultiReporter{memory, reporter}, operation, []string{root}, runOptions)
+			for _, message := range memory.Messages() {
+				if !message.Modified {
+					continue
+				}
+				if info, err := os.Stat(message.Title); err == nil {
+					written[message.Title] = info.ModTime()
+				}
+			}
+		}
+	}
+}
+
+// Adds the directory in the package root and all directories inside it to the watcher, fsnotify
+// isn't recursive. Ignored directories are skipped.
+func watchDirs(ctx context.Context, watcher *fsnotify.Watcher, config *AppConfig, root string, dirPath string) error {
+	walker := newWalker(ctx, config, NewProjectConfigs(config))
+	// Unreadable directories inside root are skipped, root itself has to work
+	var rootErr error
+	onError := func(filePath string, err error) {
+		if filePath == root && rootErr == nil {
+			rootErr = err
+		}
+	}
+	if walker.descend(root, dirPath, onError) {
+		return nil
+	}
+	walker.onDir = func(dirPath string) error {
+		return watcher.Add(dirPath)
+	}
+	if err := walker.walkDir(root, dirPath, func(string) error { return nil }, onError); err != nil {
+		return err
+	}
+	return rootErr
+}
+
+// Whether any fixer enabled for the operation handles the file.
+func watchMatches(filePath string, operation Operation, config *AppConfig, options RunOptions) bool {
+	for _, fixer := range registry {
+		name := fixer.Name()
+		if slices.Contains(fixer.Modes(), operation) && options.fixerEnabled(name) && !config.FixerDisabled(operation, name) && fixer.Match(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+func watchErrorMessage(filePath string, err error) *Message {
+	return &Message{Icon: Ptr("file"), Title: filePath, Notes: []Note{{
+		Variant: Warning,
+		Rule:    "watch-failed",
+		Code:    "CPU005",
+		Text:    "Watching for changes failed, some changes might be missed.",
+		Details: Ptr(err.Error()),
+	}}}
+}
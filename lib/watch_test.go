@@ -0,0 +1,26 @@
+package lib
+
+import "testing"
+
+func TestWatchMatchesEnabledFixers(t *testing.T) {
+	vaj := "/vam/Custom/Clothing/Female/A/I/I.vaj"
+	disabled := NewAppConfig()
+	disabled.Disabled = map[Operation][]string{OpFix: {"vaj"}}
+
+	tests := []struct {
+		name    string
+		config  *AppConfig
+		options RunOptions
+		want    bool
+	}{
+		{"all fixers", NewAppConfig(), RunOptions{}, true},
+		{"fixer enabled", NewAppConfig(), RunOptions{Fixers: []string{"vaj"}}, true},
+		{"other fixer enabled", NewAppConfig(), RunOptions{Fixers: []string{"cpl"}}, false},
+		{"fixer disabled in config", disabled, RunOptions{}, false},
+	}
+	for _, test := range tests {
+		if got := watchMatches(vaj, OpFix, test.config, test.options); got != test.want {
+			t.Errorf("%s: matches %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long a file has to stay unchanged before the change is reported, editors often write in several steps.
+const watchFileDebounce = 200 * time.Millisecond
+
+// Calls onChange every time the file is created, written, or replaced, until the context is done.
+// The parent directory is watched, so changes are picked up even when editors replace the file.
+func WatchFile(ctx context.Context, filePath string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(filePath)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(watchFileDebounce)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !strings.EqualFold(filepath.Clean(event.Name), filepath.Clean(filePath)) {
+					continue
+				}
+				if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) || event.Has(fsnotify.Rename) {
+					timer.Reset(watchFileDebounce)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-timer.C:
+				onChange()
+			}
+		}
+	}()
+
+	return nil
+}
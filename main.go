@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,8 +13,18 @@ import (
 var assets embed.FS
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cli" {
+		os.Exit(runCLI(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServe(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rpc" {
+		os.Exit(runRPC(os.Args[2:]))
+	}
+
 	// Create an instance of the app structure
-	app := NewApp()
+	app := NewApp(os.Args[1:])
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -25,6 +36,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 35, G: 32, B: 37, A: 1},
 		OnStartup:        app.startup,
+		OnDomReady:       app.domReady,
 		OnBeforeClose:    app.beforeClose,
 		Bind: []interface{}{
 			app,
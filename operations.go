@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// Contexts of running operations, so they can all be cancelled at once. Zero value is ready to use.
+type operationGroup struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Creates a context for a new operation, derived from parent, that is cancelled by `cancelAll`.
+func (g *operationGroup) start(parent context.Context) (context.Context, context.CancelFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ctx == nil {
+		g.ctx, g.cancel = context.WithCancel(parent)
+	}
+	return context.WithCancel(g.ctx)
+}
+
+// Cancels all running operations, ones started later aren't affected.
+func (g *operationGroup) cancelAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		g.cancel()
+		g.ctx = nil
+	}
+}
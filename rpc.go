@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+
+	"app/lib"
+)
+
+const rpcUsage = `Usage: %s rpc [flags]
+
+Serves JSON-RPC 2.0 over stdin/stdout, one JSON object per line, for editor
+integrations. Methods match the app's bindings and take positional or named params:
+
+  InitPaths(paths)                    FixPaths(paths)
+  FixItemsGender(paths)               RunPaths(operation, paths, options)
+  GetConfig()                         SetConfig(config)
+  ListFixers()                        Cancel()
+
+Operations return their summary, and send "message" and "progress"
+notifications while they run.
+
+Flags:
+`
+
+// Runs the JSON-RPC server until stdin is closed. Returns process exit code.
+func runRPC(args []string) int {
+	flags := flag.NewFlagSet("rpc", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), rpcUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	workers := flags.Int("workers", 0, "number of files processed in parallel (0 = config value or number of CPUs)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	server := newRPCServer(ctx, os.Stdout, *workers)
+	if err := server.serve(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// Standard JSON-RPC 2.0 error codes, and the one used for errors returned by methods.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcMethodError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Method callable over JSON-RPC. Params are decoded into values returned by params, either from
+// an array in order, or from an object by names.
+type rpcMethod struct {
+	names  []string
+	params func() []any
+	call   func(params []any) (any, error)
+}
+
+type rpcServer struct {
+	ctx     context.Context
+	workers int
+	store   *lib.ConfigStore
+	methods map[string]*rpcMethod
+
+	writeMu sync.Mutex
+	out     *json.Encoder
+
+	// Operations run one at a time, so they don't write the same files or incremental state.
+	runMu      sync.Mutex
+	operations operationGroup
+}
+
+func newRPCServer(ctx context.Context, w io.Writer, workers int) *rpcServer {
+	out := json.NewEncoder(w)
+	out.SetEscapeHTML(false)
+	s := &rpcServer{ctx: ctx, workers: workers, out: out, store: lib.NewAppConfigStore(getConfigPath("config"))}
+
+	paths := func() []any { return []any{&[]string{}} }
+	operation := func(op lib.Operation) *rpcMethod {
+		return &rpcMethod{names: []string{"paths"}, params: paths, call: func(params []any) (any, error) {
+			return s.run(op, *params[0].(*[]string), lib.RunOptions{}), nil
+		}}
+	}
+	s.methods = map[string]*rpcMethod{
+		"InitPaths":      operation(lib.OpInit),
+		"FixPaths":       operation(lib.OpFix),
+		"FixItemsGender": operation(lib.OpGender),
+		"RunPaths": {
+			names:  []string{"operation", "paths", "options"},
+			params: func() []any { return []any{new(string), &[]string{}, &lib.RunOptions{}} },
+			call: func(params []any) (any, error) {
+				op, err := lib.ParseOperation(*params[0].(*string))
+				if err != nil {
+					return nil, err
+				}
+				options := *params[2].(*lib.RunOptions)
+				if err := options.Validate(); err != nil {
+					return nil, err
+				}
+				return s.run(op, *params[1].(*[]string), options), nil
+			},
+		},
+		"GetConfig": {
+			params: func() []any { return nil },
+			call: func(params []any) (any, error) {
+				return loadConfig(0), nil
+			},
+		},
+		"SetConfig": {
+			names:  []string{"config"},
+			params: func() []any { return []any{lib.NewAppConfig()} },
+			call: func(params []any) (any, error) {
+				config := params[0].(*lib.AppConfig)
+				config.Version = lib.AppConfigVersion
+				if err := config.Validate(); err != nil {
+					return nil, err
+				}
+				return nil, s.store.Save(config)
+			},
+		},
+		"ListFixers": {
+			params: func() []any { return nil },
+			call: func(params []any) (any, error) {
+				return lib.ListFixers(), nil
+			},
+		},
+		"Cancel": {
+			params: func() []any { return nil },
+			call: func(params []any) (any, error) {
+				s.operations.cancelAll()
+				return nil, nil
+			},
+		},
+	}
+	return s
+}
+
+// Reads requests line by line until EOF. Each request is handled concurrently, so long running
+// operations can be cancelled. Returns once all of them are done.
+func (s *rpcServer) serve(r io.Reader) error {
+	var requests sync.WaitGroup
+	defer requests.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '[' {
+			s.respond(nil, nil, &rpcError{Code: rpcInvalidRequest, Message: "batch requests aren't supported"})
+			continue
+		}
+		request := &rpcRequest{}
+		if err := json.Unmarshal(line, request); err != nil {
+			s.respond(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+			continue
+		}
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			s.handle(request)
+		}()
+	}
+	return scanner.Err()
+}
+
+func (s *rpcServer) handle(request *rpcRequest) {
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		s.respond(request.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+		return
+	}
+	// Requests without an ID are notifications, which never get a response, not even an error
+	reply := func(result any, err *rpcError) {
+		if request.ID != nil {
+			s.respond(request.ID, result, err)
+		}
+	}
+
+	method, ok := s.methods[request.Method]
+	if !ok {
+		reply(nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method \"%s\"", request.Method)})
+		return
+	}
+	params := method.params()
+	if err := decodeRPCParams(request.Params, method.names, params); err != nil {
+		reply(nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()})
+		return
+	}
+
+	result, err := method.call(params)
+	if err != nil {
+		reply(nil, &rpcError{Code: rpcMethodError, Message: err.Error()})
+		return
+	}
+	reply(result, nil)
+}
+
+// Decodes positional (array) or named (object) params into values.
+func decodeRPCParams(raw json.RawMessage, names []string, values []any) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		raw = []byte("[]")
+	}
+	if raw[0] == '{' {
+		var named map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &named); err != nil {
+			return err
+		}
+		for name := range named {
+			if !slices.Contains(names, name) {
+				return fmt.Errorf("unknown param \"%s\"", name)
+			}
+		}
+		for i, name := range names {
+			if value, ok := named[name]; ok {
+				if err := json.Unmarshal(value, values[i]); err != nil {
+					return fmt.Errorf("param \"%s\": %w", name, err)
+				}
+			}
+		}
+		return nil
+	}
+
+	var positional []json.RawMessage
+	if err := json.Unmarshal(raw, &positional); err != nil {
+		return fmt.Errorf("params have to be an array or object")
+	}
+	if len(positional) > len(values) {
+		return fmt.Errorf("expected at most %d params, got %d", len(values), len(positional))
+	}
+	for i, value := range positional {
+		if err := json.Unmarshal(value, values[i]); err != nil {
+			return fmt.Errorf("param \"%s\": %w", names[i], err)
+		}
+	}
+	return nil
+}
+
+// Runs an operation once no other one is running, sending what it reports as notifications.
+func (s *rpcServer) run(operation lib.Operation, paths []string, options lib.RunOptions) *lib.Summary {
+	ctx, cancel := s.operations.start(s.ctx)
+	defer cancel()
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	options.Config = loadConfig(s.workers)
+	options.StateDir = getCacheDir("state")
+	return lib.Run(ctx, &rpcReporter{server: s}, operation, paths, options)
+}
+
+// Responds with the result, or the error when set. ID is null when the request couldn't be read.
+func (s *rpcServer) respond(id json.RawMessage, result any, err *rpcError) {
+	response := &rpcResponse{JSONRPC: "2.0", ID: id, Error: err}
+	if id == nil {
+		response.ID = json.RawMessage("null")
+	}
+	if err == nil {
+		data, marshalErr := lib.JSONMarshalCompact(result)
+		if marshalErr != nil {
+			response.Error = &rpcError{Code: rpcMethodError, Message: marshalErr.Error()}
+		} else {
+			response.Result = data
+		}
+	}
+	s.write(response)
+}
+
+func (s *rpcServer) notify(method string, params any) {
+	s.write(&rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *rpcServer) write(value any) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.out.Encode(value)
+}
+
+// Sends operation output as `message` and `progress` notifications.
+type rpcReporter struct {
+	server *rpcServer
+}
+
+func (r *rpcReporter) Message(message *lib.Message) {
+	r.server.notify("message", message)
+}
+
+func (r *rpcReporter) Progress(progress *lib.Progress) {
+	r.server.notify("progress", progress)
+}
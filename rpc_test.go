@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"app/lib"
+)
+
+func TestDecodeRPCParams(t *testing.T) {
+	names := []string{"operation", "paths", "options"}
+	tests := []struct {
+		raw     string
+		op      string
+		paths   []string
+		fixers  []string
+		wantErr bool
+	}{
+		{raw: ``},
+		{raw: `null`},
+		{raw: `[]`},
+		{raw: `["fix"]`, op: "fix"},
+		{raw: `["fix", ["a", "b"], {"fixers": ["vaj"]}]`, op: "fix", paths: []string{"a", "b"}, fixers: []string{"vaj"}},
+		{raw: `{"paths": ["a"], "operation": "gender"}`, op: "gender", paths: []string{"a"}},
+		{raw: `{"options": {"force": true, "fixers": ["cpl"]}}`, fixers: []string{"cpl"}},
+		{raw: `["fix", ["a"], {}, "extra"]`, wantErr: true},
+		{raw: `{"unknown": 1}`, wantErr: true},
+		{raw: `[1]`, wantErr: true},
+		{raw: `{"paths": "a"}`, wantErr: true},
+		{raw: `"fix"`, wantErr: true},
+	}
+	for _, test := range tests {
+		values := []any{new(string), &[]string{}, &lib.RunOptions{}}
+		err := decodeRPCParams(json.RawMessage(test.raw), names, values)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: decoded", test.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.raw, err)
+			continue
+		}
+		op, paths, options := *values[0].(*string), *values[1].(*[]string), values[2].(*lib.RunOptions)
+		if op != test.op || !slices.Equal(paths, test.paths) || !slices.Equal(options.Fixers, test.fixers) {
+			t.Errorf("%s: decoded %q, %v, %v", test.raw, op, paths, options.Fixers)
+		}
+	}
+}
+
+func TestRPCServerResponses(t *testing.T) {
+	var out bytes.Buffer
+	server := newRPCServer(context.Background(), &out, 0)
+	requests := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "ListFixers"}`,
+		`{"jsonrpc": "2.0", "method": "Nope"}`,
+		`{"jsonrpc": "2.0", "method": "FixPaths", "params": {"bad": 1}}`,
+		`{"jsonrpc": "2.0", "method": "Cancel"}`,
+		`{"jsonrpc": "2.0", "id": "a", "method": "Nope"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "FixPaths", "params": {"bad": 1}}`,
+		`{"jsonrpc": "1.0", "id": 3, "method": "Cancel"}`,
+		`not json`,
+	}, "\n")
+	if err := server.serve(strings.NewReader(requests)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests are handled concurrently, so responses are matched by ID
+	codes := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var response struct {
+			ID     json.RawMessage `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *rpcError       `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("invalid response %s: %v", line, err)
+		}
+		if _, ok := codes[string(response.ID)]; ok && string(response.ID) != "null" {
+			t.Errorf("second response to %s", response.ID)
+		}
+		code := 0
+		if response.Error != nil {
+			code = response.Error.Code
+		}
+		codes[string(response.ID)] = code
+	}
+
+	want := map[string]int{
+		`1`:    0,
+		`"a"`:  rpcMethodNotFound,
+		`2`:    rpcInvalidParams,
+		`3`:    rpcInvalidRequest,
+		`null`: rpcParseError,
+	}
+	if len(codes) != len(want) {
+		t.Errorf("got responses %v, want %v; notifications must not get any", codes, want)
+	}
+	for id, code := range want {
+		if got, ok := codes[id]; !ok || got != code {
+			t.Errorf("response to %s has code %d, want %d", id, got, code)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"app/lib"
+)
+
+const serveUsage = `Usage: %s serve [flags]
+
+Serves operations over a local HTTP JSON API. Operations stream their messages
+and progress updates as newline delimited JSON, or as server-sent events when
+requested with "Accept: text/event-stream".
+
+  GET  /fixers     available fixers
+  POST /run        {"operation": "fix", "paths": [...], "fixers": [...], "force": false}
+  POST /build      {"path": "<package directory>", "output": "<.var file>"}
+  POST /job        {"file": "<job file>"}
+
+Flags:
+`
+
+// Runs the API server until interrupted. Returns process exit code.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), serveUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	addr := flags.String("addr", "127.0.0.1:7823", "loopback `address` to listen on")
+	workers := flags.Int("workers", 0, "number of files processed in parallel (0 = config value or number of CPUs)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	if err := checkLoopback(*addr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	api := &apiServer{workers: *workers}
+	server := &http.Server{Addr: *addr, Handler: api.handler(), BaseContext: func(net.Listener) context.Context { return ctx }}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on http://%s\n", *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// Only loopback addresses are allowed, the API can modify any file the user can.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("refusing to listen on non-loopback address \"%s\"", addr)
+	}
+	return nil
+}
+
+type apiServer struct {
+	workers int
+	// Operations run one at a time, so they don't write the same files or incremental state.
+	runMu sync.Mutex
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /fixers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, lib.ListFixers())
+	})
+	mux.HandleFunc("POST /run", s.handleRun)
+	mux.HandleFunc("POST /build", s.handleBuild)
+	mux.HandleFunc("POST /job", s.handleJob)
+	return guardLocal(mux)
+}
+
+// Rejects requests from browsers, which send an Origin, and ones whose Host isn't local, e.g.
+// through DNS rebinding. Other local tools don't send either.
+func guardLocal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if r.Header.Get("Origin") != "" || checkLoopback(net.JoinHostPort(host, "0")) != nil {
+			writeError(w, http.StatusForbidden, fmt.Errorf("only local tools can use the API"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type runRequestBody struct {
+	Operation string   `json:"operation"`
+	Paths     []string `json:"paths"`
+	Fixers    []string `json:"fixers"`
+	Force     bool     `json:"force"`
+}
+
+func (s *apiServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	var body runRequestBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	operation, err := lib.ParseOperation(body.Operation)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(body.Paths) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no paths"))
+		return
+	}
+	options := lib.RunOptions{Fixers: body.Fixers, Force: body.Force, StateDir: getCacheDir("state")}
+	if err := options.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.stream(w, r, func(reporter lib.Reporter) {
+		options.Config = loadConfig(s.workers)
+		lib.Run(r.Context(), reporter, operation, body.Paths, options)
+	})
+}
+
+type buildRequestBody struct {
+	Path string `json:"path"`
+	// Defaults to `AddonPackages/<package>.var` next to `AddonPackagesBuilder`.
+	Output string `json:"output"`
+}
+
+func (s *apiServer) handleBuild(w http.ResponseWriter, r *http.Request) {
+	var body buildRequestBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Path == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no path"))
+		return
+	}
+	if body.Output == "" {
+		body.Output = lib.DefaultPackageOutput(body.Path)
+	}
+
+	s.stream(w, r, func(reporter lib.Reporter) {
+		lib.BuildPackage(r.Context(), reporter, body.Path, body.Output, loadConfig(s.workers))
+	})
+}
+
+type jobRequestBody struct {
+	File string `json:"file"`
+}
+
+func (s *apiServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	var body jobRequestBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	job, err := lib.LoadJob(body.File)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.stream(w, r, func(reporter lib.Reporter) {
+		options := lib.RunOptions{Config: loadConfig(s.workers), StateDir: getCacheDir("state")}
+		lib.RunJob(r.Context(), reporter, job, options)
+	})
+}
+
+// Runs the operation once no other one is running, and streams what it reports to the response.
+// Operation is cancelled when the client disconnects.
+func (s *apiServer) stream(w http.ResponseWriter, r *http.Request, run func(reporter lib.Reporter)) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if r.Context().Err() != nil {
+		return
+	}
+
+	out := &flushWriter{w: w}
+	out.flusher, _ = w.(http.Flusher)
+	var reporter lib.Reporter
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		reporter = lib.NewEventStreamReporter(out)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		reporter = lib.NewJSONLinesReporter(out)
+	}
+	w.WriteHeader(http.StatusOK)
+	run(reporter)
+}
+
+// Flushes after every write, so each message reaches the client as soon as it's reported.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+	return n, err
+}
+
+// Decodes the request body, responds with an error when it's invalid.
+func readJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	data, _ := lib.JSONMarshalCompact(value)
+	w.Write(append(data, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}